@@ -0,0 +1,300 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+
+	"github.com/walles/moor/v2/internal"
+	"github.com/walles/moor/v2/internal/config"
+	"github.com/walles/moor/v2/internal/reader"
+)
+
+// Config file sections that aren't file type sections
+const keysSectionName = "keys"
+
+// Applies to man pages, when MAN_PN is set by man
+const manSectionName = "man"
+
+// Options that can be set in file type sections of the config file
+var fileTypeOptionNames = []string{"wrap", "no-linenumbers", "tab-size", "lang", "reformat"}
+
+// Keeps track of where option values came from, for moor --help
+type optionSources struct {
+	// Origin of the values being set right now
+	current string
+
+	// Set while parsing the MOOR environment variable or the command line.
+	// Those beat file type sections in the config file.
+	currentIsUser bool
+
+	// Option name to where it was set
+	origins map[string]string
+
+	// Option name to the value as it was given
+	values map[string]string
+
+	// Options set from the environment or the command line
+	setByUser map[string]bool
+}
+
+func newOptionSources() *optionSources {
+	return &optionSources{
+		origins:   map[string]string{},
+		values:    map[string]string{},
+		setByUser: map[string]bool{},
+	}
+}
+
+func (sources *optionSources) record(name string, value string) {
+	sources.origins[name] = sources.current
+	sources.values[name] = value
+	sources.setByUser[name] = sources.currentIsUser
+}
+
+// Parse the args, and record where the options in them came from. Stops at the
+// first non-option, like flagSet.Parse() does.
+func parseOptions(flagSet *flag.FlagSet, sources *optionSources, args []string) error {
+	err := flagSet.Parse(args)
+
+	// Find the options flagSet.Parse() just consumed
+	var consumed []string
+	switch {
+	case err == nil:
+		consumed = args[:len(args)-len(flagSet.Args())]
+	case errors.Is(err, flag.ErrHelp):
+		// Record the options before --help, so that it can show them. Note
+		// that --help=false also makes flagSet.Parse() ask for help.
+		helpIndex := slices.IndexFunc(args, func(arg string) bool {
+			name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			return name == "h" || name == "help"
+		})
+		if helpIndex >= 0 {
+			consumed = args[:helpIndex]
+		}
+	default:
+		return err
+	}
+
+	for i := 0; i < len(consumed); i++ {
+		name := strings.TrimLeft(consumed[i], "-")
+		if name == "" {
+			// "--" ends the options
+			break
+		}
+
+		name, value, hasValue := strings.Cut(name, "=")
+		if !hasValue {
+			value = "true"
+			if !isBoolFlag(flagSet.Lookup(name)) && i+1 < len(consumed) {
+				i++
+				value = consumed[i]
+			}
+		}
+
+		sources.record(name, value)
+	}
+
+	return err
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	if f == nil {
+		return false
+	}
+	boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && boolFlag.IsBoolFlag()
+}
+
+// Set options from the top of the config file, before any sections. Options
+// that can't be set are skipped, and returned as problems.
+func applyConfigFileOptions(flagSet *flag.FlagSet, sources *optionSources, file *config.File) []error {
+	section := file.Section("")
+	if section == nil {
+		return nil
+	}
+
+	var problems []error
+	for _, name := range section.Keys {
+		value := section.Values[name]
+		if flagSet.Lookup(name) == nil {
+			problems = append(problems, fmt.Errorf("%s: unknown option %q", file.Path, name))
+			continue
+		}
+		if value.IsList {
+			problems = append(problems, fmt.Errorf("%s: %s takes one value, not a list", file.Path, name))
+			continue
+		}
+
+		err := flagSet.Set(name, value.String())
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: invalid value for %s: %w", file.Path, name, err))
+			continue
+		}
+
+		sources.current = file.Path
+		sources.currentIsUser = false
+		sources.record(name, value.String())
+	}
+
+	return problems
+}
+
+// Options from one file type section of the config file, like ["*.md"] or
+// [man]. Nil fields are not set by the section.
+type fileTypeSettings struct {
+	// A file name glob, or manSectionName
+	pattern string
+
+	wrap          *bool
+	noLineNumbers *bool
+	tabSize       *uint
+	lexer         chroma.Lexer
+	reformat      *bool
+
+	// Option name to "path [section]", for moor --help
+	origins map[string]string
+
+	// Option name to the value as it was given, for moor --help
+	values map[string]string
+}
+
+// Parse the file type sections of the config file, in file order. Sections and
+// options that can't be used are skipped, and returned as problems.
+func parseFileTypeSections(file *config.File) ([]fileTypeSettings, []error) {
+	result := []fileTypeSettings{}
+	var problems []error
+	for _, name := range file.SectionNames {
+		if name == keysSectionName {
+			continue
+		}
+
+		if name != manSectionName {
+			if _, err := filepath.Match(name, ""); err != nil {
+				problems = append(problems, fmt.Errorf("%s: [%s] is not a valid file name pattern: %w", file.Path, name, err))
+				continue
+			}
+		}
+
+		settings, sectionProblems := parseFileTypeSection(file.Path, file.Section(name))
+		problems = append(problems, sectionProblems...)
+		result = append(result, settings)
+	}
+
+	return result, problems
+}
+
+func parseFileTypeSection(path string, section *config.Section) (fileTypeSettings, []error) {
+	settings := fileTypeSettings{
+		pattern: section.Name,
+		origins: map[string]string{},
+		values:  map[string]string{},
+	}
+
+	var problems []error
+	for _, name := range section.Keys {
+		value := section.Values[name]
+		if !slices.Contains(fileTypeOptionNames, name) {
+			problems = append(problems, fmt.Errorf("%s: %s can't be set for [%s], only these can: %v", path, name, section.Name, fileTypeOptionNames))
+			continue
+		}
+		if value.IsList {
+			problems = append(problems, fmt.Errorf("%s: [%s] %s takes one value, not a list", path, section.Name, name))
+			continue
+		}
+
+		var err error
+		switch name {
+		case "wrap":
+			settings.wrap, err = parseBoolPointer(value.String())
+		case "no-linenumbers":
+			settings.noLineNumbers, err = parseBoolPointer(value.String())
+		case "reformat":
+			settings.reformat, err = parseBoolPointer(value.String())
+		case "tab-size":
+			var tabSize uint
+			tabSize, err = parseTabAmount(value.String())
+			if err == nil {
+				settings.tabSize = &tabSize
+			}
+		case "lang":
+			settings.lexer, err = parseLexerOption(value.String())
+		}
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: [%s] invalid value for %s: %w", path, section.Name, name, err))
+			continue
+		}
+
+		settings.origins[name] = fmt.Sprintf("%s [%s]", path, section.Name)
+		settings.values[name] = value.String()
+	}
+
+	return settings, problems
+}
+
+func parseBoolPointer(value string) (*bool, error) {
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// Does this section apply to the named file? The file name is empty for
+// unnamed streams.
+func (s fileTypeSettings) matches(fileName string, isManPage bool) bool {
+	if s.pattern == manSectionName {
+		return isManPage
+	}
+
+	if fileName == "" {
+		return false
+	}
+
+	matched, err := filepath.Match(s.pattern, filepath.Base(fileName))
+	return err == nil && matched
+}
+
+// Apply all matching file type sections to the options for one file. Options
+// set in the environment or on the command line take precedence.
+func optionsForFile(
+	sections []fileTypeSettings,
+	sources *optionSources,
+	fileName string,
+	isManPage bool,
+	readerOptions reader.ReaderOptions,
+) (reader.ReaderOptions, internal.FileSettings) {
+	fileSettings := internal.FileSettings{}
+
+	for _, section := range sections {
+		if !section.matches(fileName, isManPage) {
+			continue
+		}
+
+		if section.wrap != nil && !sources.setByUser["wrap"] {
+			fileSettings.WrapLongLines = section.wrap
+		}
+		if section.noLineNumbers != nil && !sources.setByUser["no-linenumbers"] {
+			showLineNumbers := !*section.noLineNumbers
+			fileSettings.ShowLineNumbers = &showLineNumbers
+		}
+		if section.tabSize != nil && !sources.setByUser["tab-size"] {
+			tabSize := int(*section.tabSize)
+			fileSettings.TabSize = &tabSize
+		}
+		if section.lexer != nil && !sources.setByUser["lang"] {
+			readerOptions.Lexer = section.lexer
+		}
+		if section.reformat != nil && !sources.setByUser["reformat"] {
+			readerOptions.ShouldFormat = *section.reformat
+		}
+	}
+
+	return readerOptions, fileSettings
+}
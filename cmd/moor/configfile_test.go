@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/walles/moor/v2/internal"
+	"github.com/walles/moor/v2/internal/config"
+	"github.com/walles/moor/v2/internal/reader"
+	"gotest.tools/v3/assert"
+)
+
+func newTestFlagSet() (*flag.FlagSet, *bool, *uint) {
+	flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	wrap := flagSet.Bool("wrap", false, "Wrap long lines")
+	tabSize := flagSetFunc(flagSet, "tab-size", 8, "Tab size", parseTabAmount)
+	flagSet.String("style", "", "Style")
+	return flagSet, wrap, tabSize
+}
+
+func TestOptionSources(t *testing.T) {
+	flagSet, wrap, tabSize := newTestFlagSet()
+	sources := newOptionSources()
+
+	file, err := config.Parse("wrap = true\ntab-size = 4\n")
+	assert.NilError(t, err)
+	file.Path = "config.toml"
+	assert.Equal(t, len(applyConfigFileOptions(flagSet, sources, file)), 0)
+
+	sources.current = "command line"
+	sources.currentIsUser = true
+	assert.NilError(t, parseOptions(flagSet, sources, []string{"--tab-size", "2", "-style=monokai", "file.txt"}))
+
+	assert.Equal(t, *wrap, true)
+	assert.Equal(t, *tabSize, uint(2))
+	assert.DeepEqual(t, flagSet.Args(), []string{"file.txt"})
+
+	assert.DeepEqual(t, sources.origins, map[string]string{
+		"wrap":     "config.toml",
+		"tab-size": "command line",
+		"style":    "command line",
+	})
+	assert.DeepEqual(t, sources.values, map[string]string{
+		"wrap":     "true",
+		"tab-size": "2",
+		"style":    "monokai",
+	})
+	assert.Assert(t, !sources.setByUser["wrap"])
+	assert.Assert(t, sources.setByUser["tab-size"])
+}
+
+func TestOptionSourcesBeforeHelp(t *testing.T) {
+	flagSet, _, _ := newTestFlagSet()
+	sources := newOptionSources()
+	sources.current = "command line"
+
+	err := parseOptions(flagSet, sources, []string{"--wrap", "--help", "--style=x"})
+	assert.Equal(t, err, flag.ErrHelp)
+	assert.DeepEqual(t, sources.values, map[string]string{"wrap": "true"})
+
+	// Used to panic
+	err = parseOptions(flagSet, sources, []string{"--help=true"})
+	assert.Equal(t, err, flag.ErrHelp)
+	err = parseOptions(flagSet, sources, []string{"-h=false"})
+	assert.Equal(t, err, flag.ErrHelp)
+}
+
+// Bad options should be reported and skipped, without stopping the good ones
+func TestConfigFileOptionErrors(t *testing.T) {
+	flagSet, wrap, _ := newTestFlagSet()
+
+	file, err := config.Parse("fly = true\ntab-size = 0\nwrap = true\n")
+	assert.NilError(t, err)
+	file.Path = "config.toml"
+	problems := applyConfigFileOptions(flagSet, newOptionSources(), file)
+	assert.Equal(t, len(problems), 2)
+	assert.Error(t, problems[0], `config.toml: unknown option "fly"`)
+	assert.Error(t, problems[1], "config.toml: invalid value for tab-size: Tab size must be at least 1")
+	assert.Equal(t, *wrap, true)
+
+	file, err = config.Parse("[man]\nstyle = \"monokai\"\ntab-size = 0\nwrap = true\n")
+	assert.NilError(t, err)
+	file.Path = "config.toml"
+	sections, problems := parseFileTypeSections(file)
+	assert.Equal(t, len(problems), 2)
+	assert.ErrorContains(t, problems[0], "config.toml: style can't be set for [man]")
+	assert.Error(t, problems[1], "config.toml: [man] invalid value for tab-size: Tab size must be at least 1")
+	assert.Equal(t, len(sections), 1)
+	assert.Assert(t, sections[0].tabSize == nil)
+	assert.Equal(t, *sections[0].wrap, true)
+}
+
+func TestOptionsForFile(t *testing.T) {
+	file, err := config.Parse(`
+[keys]
+quit = "x"
+
+["*.md"]
+wrap = true
+tab-size = 4
+
+[man]
+no-linenumbers = true
+`)
+	assert.NilError(t, err)
+	sections, problems := parseFileTypeSections(file)
+	assert.Equal(t, len(problems), 0)
+	assert.Equal(t, len(sections), 2)
+
+	sources := newOptionSources()
+	sources.current = "command line"
+	sources.currentIsUser = true
+	sources.record("tab-size", "3")
+
+	_, settings := optionsForFile(sections, sources, "docs/README.md", false, reader.ReaderOptions{})
+	assert.Equal(t, *settings.WrapLongLines, true)
+	assert.Assert(t, settings.TabSize == nil, "Command line tab size should win")
+	assert.Assert(t, settings.ShowLineNumbers == nil)
+
+	_, settings = optionsForFile(sections, sources, "", true, reader.ReaderOptions{})
+	assert.Equal(t, *settings.ShowLineNumbers, false)
+	assert.Assert(t, settings.WrapLongLines == nil)
+
+	_, settings = optionsForFile(sections, sources, "main.go", false, reader.ReaderOptions{})
+	assert.DeepEqual(t, settings, internal.FileSettings{})
+}
@@ -22,6 +22,7 @@ import (
 	"golang.org/x/term"
 
 	"github.com/walles/moor/v2/internal"
+	"github.com/walles/moor/v2/internal/config"
 	"github.com/walles/moor/v2/internal/linemetadata"
 	"github.com/walles/moor/v2/internal/reader"
 	"github.com/walles/moor/v2/internal/textstyles"
@@ -384,6 +385,8 @@ func pagerFromArgs(
 		"File contents, used for highlighting. Mime type or file extension (\"html\"). Default is to guess by filename.", parseLexerOption)
 	terminalFg := flagSet.Bool("terminal-fg", false, "Use terminal foreground color rather than style foreground for plain text")
 	noSearchLineHighlight := flagSet.Bool("no-search-line-highlight", false, "Do not highlight the background of lines with search hits")
+	searchAcrossFiles := flagSet.Bool("search-across-files", false, "Let 'n' and 'p' continue searching in the next / previous file")
+	diff := flagSet.Bool("diff", false, "Compare two files side by side, 'moor --diff old.txt new.txt'")
 
 	defaultFormatter, err := parseColorsOption("auto")
 	if err != nil {
@@ -420,28 +423,63 @@ func pagerFromArgs(
 		parseMouseMode,
 	)
 
-	// Combine flags from environment and from command line
-	flags := args[1:]
-	moorEnv := strings.TrimSpace(os.Getenv(moorEnvVarName()))
-	if len(moorEnv) > 0 {
-		// FIXME: It would be nice if we could debug log that we're doing this,
-		// but logging is not yet set up and depends on command line parameters.
-		flags = append(strings.Fields(moorEnv), flags...)
+	sources := newOptionSources()
+
+	// Options are read from the config file, then from the environment and
+	// then from the command line. Later ones win.
+	//
+	// Problems with the config file or the environment are warned about, but
+	// shouldn't stop us from paging.
+	var startupProblems []error
+	configFile, err := config.Load(config.DefaultPath())
+	if err != nil {
+		startupProblems = append(startupProblems, err)
+		configFile = &config.File{Path: config.DefaultPath(), Sections: map[string]*config.Section{}}
+	}
+	startupProblems = append(startupProblems, applyConfigFileOptions(flagSet, sources, configFile)...)
+	fileTypeSections, problems := parseFileTypeSections(configFile)
+	startupProblems = append(startupProblems, problems...)
+	keymap, problems := internal.KeymapFromConfig(configFile.Section(keysSectionName))
+	for _, problem := range problems {
+		startupProblems = append(startupProblems, fmt.Errorf("%s: %w", configFile.Path, problem))
+	}
+
+	envVarName := moorEnvVarName()
+	envFlags, err := splitShellWords(os.Getenv(envVarName))
+	if err != nil {
+		startupProblems = append(startupProblems, fmt.Errorf("Ignoring the %s environment variable: %w", envVarName, err))
+		envFlags = nil
 	}
 
-	targetLine, initialSearch, remainingArgs := parsePlusArgs(flags)
+	// FIXME: It would be nice if we could debug log that we're doing this,
+	// but logging is not yet set up and depends on command line parameters.
+	targetLine, initialSearch, envRemainingArgs := parsePlusArgs(envFlags)
 
-	err = flagSet.Parse(remainingArgs)
+	sources.current = envVarName + " environment variable"
+	sources.currentIsUser = true
+	err = parseOptions(flagSet, sources, envRemainingArgs)
+
+	// Anything after the options in the environment is treated as if it
+	// was first on the command line
+	remainingArgs := flagSet.Args()
 
 	if err == nil {
-		if *noClearOnExitMargin < 0 {
-			err = fmt.Errorf("Invalid --no-clear-on-exit-margin %d, must be 0 or higher", *noClearOnExitMargin)
+		commandLineTarget, commandLineSearch, commandLineArgs := parsePlusArgs(args[1:])
+		if commandLineTarget != nil {
+			targetLine = commandLineTarget
+		}
+		if commandLineSearch != nil {
+			initialSearch = commandLineSearch
 		}
+
+		sources.current = "command line"
+		sources.currentIsUser = true
+		err = parseOptions(flagSet, sources, append(remainingArgs, commandLineArgs...))
 	}
 
 	if err != nil {
 		if err == flag.ErrHelp {
-			printUsage(flagSet, *terminalColorsCount)
+			printUsage(flagSet, *terminalColorsCount, configFile, sources, fileTypeSections)
 			return nil, nil, chroma.Style{}, nil, false, nil
 		}
 
@@ -477,6 +515,10 @@ func pagerFromArgs(
 		TimestampFormat: time.StampMicro,
 	})
 
+	for _, problem := range startupProblems {
+		log.Warn(problem)
+	}
+
 	flagSetArgs := flagSet.Args()
 	if stdinIsRedirected && len(flagSetArgs) == 0 {
 		// "-" is special if stdin is redirected, means "read from stdin"
@@ -500,6 +542,11 @@ func pagerFromArgs(
 		}
 	}
 
+	if *diff && len(flagSetArgs) != 2 {
+		fmt.Fprintln(os.Stderr, "ERROR: --diff needs exactly two files to compare (\"moor --diff old.txt new.txt\")")
+		os.Exit(1)
+	}
+
 	if len(flagSetArgs) == 0 && !stdinIsRedirected {
 		fmt.Fprintln(os.Stderr, "ERROR: Filename(s) or input pipe required (\"moor file.txt\")")
 		fmt.Fprintln(os.Stderr)
@@ -534,6 +581,7 @@ func pagerFromArgs(
 	}
 
 	var readerImpls []*reader.ReaderImpl
+	var readerFileSettings []internal.FileSettings
 	shouldFormat := *reFormat
 	readerOptions := reader.ReaderOptions{Lexer: *lexer, ShouldFormat: shouldFormat}
 
@@ -557,7 +605,11 @@ func pagerFromArgs(
 				continue
 			}
 
-			readerImpl, err = reader.NewFromStream(stdinName, os.Stdin, formatter, readerOptions)
+			isManPage := os.Getenv("MAN_PN") != ""
+			stdinOptions, fileSettings := optionsForFile(fileTypeSections, sources, stdinName, isManPage, readerOptions)
+			readerFileSettings = append(readerFileSettings, fileSettings)
+
+			readerImpl, err = reader.NewFromStream(stdinName, os.Stdin, formatter, stdinOptions)
 			if err != nil {
 				return nil, nil, chroma.Style{}, nil, logsRequested, err
 			}
@@ -568,7 +620,10 @@ func pagerFromArgs(
 
 			stdinDone = true
 		} else {
-			readerImpl, err = reader.NewFromFilename(inputFilename, formatter, readerOptions)
+			fileOptions, fileSettings := optionsForFile(fileTypeSections, sources, inputFilename, false, readerOptions)
+			readerFileSettings = append(readerFileSettings, fileSettings)
+
+			readerImpl, err = reader.NewFromFilename(inputFilename, formatter, fileOptions)
 		}
 
 		if err != nil {
@@ -617,6 +672,16 @@ func pagerFromArgs(
 	pager.SideScrollAmount = int(*shift)
 	pager.TabSize = int(*tabSize)
 	pager.WithSearchHitLineBackground = !*noSearchLineHighlight
+	pager.SearchAcrossFiles = *searchAcrossFiles
+	pager.Diff = *diff
+	pager.OptionsForFile = func(fileName string) (reader.ReaderOptions, internal.FileSettings) {
+		return optionsForFile(fileTypeSections, sources, fileName, false, readerOptions)
+	}
+
+	pager.SetKeymap(keymap)
+	for i, readerImpl := range readerImpls {
+		pager.SetFileSettings(readerImpl, readerFileSettings[i])
+	}
 
 	if value, err := strconv.Atoi(os.Getenv("PAGER_WRAP_COLUMNS")); err == nil {
 		pager.Width = value
@@ -626,6 +691,13 @@ func pagerFromArgs(
 		pager.InitialSearch = *initialSearch
 	}
 
+	if len(startupProblems) > 0 {
+		pager.StartupWarning = startupProblems[0].Error()
+		if len(startupProblems) > 1 {
+			pager.StartupWarning += fmt.Sprintf(" (and %d more, see moor --debug)", len(startupProblems)-1)
+		}
+	}
+
 	pager.TargetLine = targetLine
 	if *follow && pager.TargetLine == nil {
 		reallyHigh := linemetadata.IndexMax()
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// Split a string into words like a shell would, so that options with spaces in
+// them can be quoted in the MOOR environment variable:
+//
+//	MOOR='--scroll-left-hint="ESC[7m <"'
+//
+// Single quotes keep everything as is. Inside of double quotes, backslash
+// escapes double quotes and backslashes. Outside of quotes, backslashes are
+// kept as is, so that Windows paths work unquoted.
+func splitShellWords(s string) ([]string, error) {
+	words := []string{}
+
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, char := range s {
+		switch {
+		case escaped:
+			if char != '"' && char != '\\' {
+				// Not an escape after all, like "C:\Users"
+				word.WriteRune('\\')
+			}
+			word.WriteRune(char)
+			escaped = false
+
+		case quote == '\'':
+			if char == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(char)
+			}
+
+		case quote == '"':
+			if char == '\\' {
+				escaped = true
+			} else if char == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(char)
+			}
+
+		case char == '\'' || char == '"':
+			quote = char
+			inWord = true
+
+		case char == ' ' || char == '\t' || char == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+
+		default:
+			word.WriteRune(char)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, errors.New("missing closing " + string(quote))
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	words, err := splitShellWords("  --wrap   --statusbar=bold ")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, words, []string{"--wrap", "--statusbar=bold"})
+
+	words, err = splitShellWords(`--scroll-left-hint="ESC[7m <" --style 'monokai light' ""`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, words, []string{"--scroll-left-hint=ESC[7m <", "--style", "monokai light", ""})
+
+	words, err = splitShellWords(`"it's" 'say "hi"' "\"quoted\"" "back\\slash"`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, words, []string{"it's", `say "hi"`, `"quoted"`, `back\slash`})
+
+	_, err = splitShellWords(`--style "monokai`)
+	assert.Error(t, err, `missing closing "`)
+
+	_, err = splitShellWords(`--style 'monokai`)
+	assert.Error(t, err, `missing closing '`)
+}
+
+// Backslashes are path separators on Windows
+func TestSplitShellWordsBackslashPaths(t *testing.T) {
+	words, err := splitShellWords(`--search-history-file=C:\Users\me\hist "C:\Program Files\x" --wrap\`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, words, []string{`--search-history-file=C:\Users\me\hist`, `C:\Program Files\x`, `--wrap\`})
+}
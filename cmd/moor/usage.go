@@ -12,6 +12,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/walles/moor/v2/internal"
+	"github.com/walles/moor/v2/internal/config"
 	"github.com/walles/moor/v2/twin"
 )
 
@@ -126,7 +127,7 @@ func heading(text string, colors twin.ColorCount) string {
 	return prefix + text + suffix
 }
 
-func printUsage(flagSet *flag.FlagSet, colors twin.ColorCount) {
+func printUsage(flagSet *flag.FlagSet, colors twin.ColorCount, configFile *config.File, sources *optionSources, fileTypeSections []fileTypeSettings) {
 	// This controls where PrintDefaults() prints, see below
 	flagSet.SetOutput(os.Stdout)
 
@@ -134,13 +135,14 @@ func printUsage(flagSet *flag.FlagSet, colors twin.ColorCount) {
 
 	fmt.Println(heading("Usage", colors))
 	fmt.Println("  moor [options] <file>...")
+	fmt.Println("  moor --diff <old file> <new file>")
 	fmt.Println("  ... | moor")
 	fmt.Println("  moor < file")
 	fmt.Println()
 	fmt.Println("Shows file contents. Compressed files will be transparently decompressed.")
 	fmt.Println("Input is expected to be (possibly compressed) UTF-8 encoded text. Invalid /")
-	fmt.Println("non-printable characters are by default rendered as '?'. Press : inside of")
-	fmt.Println("moor to switch between files.")
+	fmt.Println("non-printable characters are by default rendered as '?'. Type :n and :p inside")
+	fmt.Println("of moor to switch between files.")
 	fmt.Println()
 	fmt.Println("More information + source code:")
 	fmt.Println("  <https://github.com/walles/moor#readme>")
@@ -193,6 +195,7 @@ func printUsage(flagSet *flag.FlagSet, colors twin.ColorCount) {
 		envSection += renderPagerEnvVar(name, colors)
 	}
 
+	envSection += renderPlainEnvVar("MOOR_EDITOR_LINE_ARG")
 	envSection += renderPlainEnvVar("TERM")
 	envSection += renderPlainEnvVar("TERM_PROGRAM")
 	envSection += renderPlainEnvVar("COLORTERM")
@@ -209,6 +212,10 @@ func printUsage(flagSet *flag.FlagSet, colors twin.ColorCount) {
 
 	printSetDefaultPagerHelp(colors)
 
+	fmt.Println()
+	fmt.Println(heading("Config File", colors))
+	printSettingSources(flagSet, configFile, sources, fileTypeSections)
+
 	fmt.Println()
 	fmt.Println(heading("Options", colors))
 
@@ -218,6 +225,56 @@ func printUsage(flagSet *flag.FlagSet, colors twin.ColorCount) {
 	fmt.Println("    \tImmediately scroll to line 1234")
 }
 
+// List the options that don't have their default values, and where each value
+// came from
+func printSettingSources(flagSet *flag.FlagSet, configFile *config.File, sources *optionSources, fileTypeSections []fileTypeSettings) {
+	if configFile.Path == "" {
+		fmt.Printf("  Options and key bindings can be set in %s.\n", config.DefaultPath())
+		fmt.Println("  But currently, that file does not exist.")
+	} else {
+		fmt.Printf("  Options and key bindings are read from %s.\n", configFile.Path)
+	}
+
+	fmt.Println()
+	nonDefaults := 0
+	flagSet.VisitAll(func(f *flag.Flag) {
+		origin, found := sources.origins[f.Name]
+		if !found {
+			return
+		}
+
+		fmt.Printf("  %s=%s (from %s)\n", f.Name, sources.values[f.Name], origin)
+		nonDefaults++
+	})
+	if nonDefaults == 0 {
+		fmt.Println("  All options have their default values.")
+	} else {
+		fmt.Println("  All other options have their default values.")
+	}
+
+	for _, section := range fileTypeSections {
+		fmt.Println()
+		if section.pattern == manSectionName {
+			fmt.Println("  For man pages:")
+		} else {
+			fmt.Printf("  For files matching %s:\n", section.pattern)
+		}
+
+		for _, name := range fileTypeOptionNames {
+			origin, found := section.origins[name]
+			if !found {
+				continue
+			}
+
+			overridden := ""
+			if sources.setByUser[name] {
+				overridden = ", overridden by the " + sources.origins[name]
+			}
+			fmt.Printf("    %s=%s (from %s%s)\n", name, section.values[name], origin, overridden)
+		}
+	}
+}
+
 // If $PAGER isn't pointing to us, print a help text on how to set it.
 func printSetDefaultPagerHelp(colors twin.ColorCount) {
 	absMoorPath, err := absLookPath(os.Args[0])
@@ -3,6 +3,7 @@ module github.com/walles/moor/v2
 go 1.25.0
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/adrg/xdg v0.5.3
 	github.com/alecthomas/chroma/v2 v2.22.0
 	github.com/charlievieth/strcase v0.0.5
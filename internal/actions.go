@@ -0,0 +1,319 @@
+package internal
+
+import (
+	"github.com/walles/moor/v2/internal/linemetadata"
+)
+
+// Something the user can do by pressing a key while viewing. Action names are
+// used in the [keys] section of the config file.
+type action string
+
+const (
+	actionQuit            action = "quit"
+	actionToggleWrap      action = "toggle-wrap"
+	actionToggleStatusBar action = "toggle-status-bar"
+	actionEdit            action = "edit"
+	actionCycleTabSize    action = "cycle-tab-size"
+	actionReload          action = "reload"
+	actionSave            action = "save"
+	actionPipe            action = "pipe"
+	actionShellCommand    action = "shell-command"
+	actionHelp            action = "help"
+	actionCommand         action = "command"
+	actionListFiles       action = "list-files"
+	actionSelectLines     action = "select-lines"
+	actionCopyLine        action = "copy-line"
+	actionToggleCursor    action = "toggle-cursor"
+
+	actionScrollUp         action = "scroll-up"
+	actionScrollDown       action = "scroll-down"
+	actionScrollLeft       action = "scroll-left"
+	actionScrollRight      action = "scroll-right"
+	actionScrollLeftOne    action = "scroll-left-one"
+	actionScrollRightOne   action = "scroll-right-one"
+	actionScrollToLeftEdge action = "scroll-to-left-edge"
+	actionPageUp           action = "page-up"
+	actionPageDown         action = "page-down"
+	actionHalfPageUp       action = "half-page-up"
+	actionHalfPageDown     action = "half-page-down"
+	actionGoToStart        action = "go-to-start"
+	actionGoToEnd          action = "go-to-end"
+	actionGoToLine         action = "go-to-line"
+	actionSetMark          action = "set-mark"
+	actionJumpToMark       action = "jump-to-mark"
+	actionJumpBack         action = "jump-back"
+	actionJumpForward      action = "jump-forward"
+	actionSearch           action = "search"
+	actionSearchBackwards  action = "search-backwards"
+	actionSearchNext       action = "search-next"
+	actionSearchPrevious   action = "search-previous"
+	actionListHits         action = "list-hits"
+	actionAddHighlight     action = "add-highlight"
+	actionListHighlights   action = "list-highlights"
+	actionFilter           action = "filter"
+	actionPopFilter        action = "pop-filter"
+	actionFilterContext    action = "filter-context"
+	actionSplitBelow       action = "split-below"
+	actionSplitRight       action = "split-right"
+	actionNextPane         action = "next-pane"
+	actionClosePane        action = "close-pane"
+	actionNextChange       action = "next-change"
+	actionPreviousChange   action = "previous-change"
+)
+
+type actionInfo struct {
+	name action
+
+	// Help text section heading
+	section string
+
+	// Shown after the keys in the help text
+	description string
+
+	defaultKeys []string
+
+	run func(p *Pager)
+}
+
+const (
+	sectionMiscellaneous = "Miscellaneous"
+	sectionMovingAround  = "Moving around"
+	sectionSearching     = "Searching"
+	sectionFiltering     = "Filtering"
+	sectionPanes         = "Split screen"
+	sectionDiff          = "Comparing files"
+)
+
+// All actions, in the order they are presented in the help text. Initialized in
+// init() since some run functions end up looking actions up in this list,
+// which would otherwise be an initialization cycle.
+var allActions []actionInfo
+
+func init() {
+	allActions = []actionInfo{
+		{actionQuit, sectionMiscellaneous, "quit", []string{"ESC", "q"}, (*Pager).Quit},
+		{actionToggleWrap, sectionMiscellaneous, "toggle wrapping of long lines", []string{"w"}, (*Pager).toggleWrap},
+		{actionToggleStatusBar, sectionMiscellaneous, "toggle showing the status bar at the bottom", []string{"="}, func(p *Pager) {
+			p.ShowStatusBar = !p.ShowStatusBar
+		}},
+		{actionEdit, sectionMiscellaneous, "edit the file in your favorite editor, at the current line", []string{"v"}, handleEditingRequest},
+		{actionCycleTabSize, sectionMiscellaneous, "change the tab size", []string{"CTRL-t"}, (*Pager).cycleTabSize},
+		{actionReload, sectionMiscellaneous, "reload the current file", []string{"r"}, (*Pager).ReloadCurrentReader},
+		{actionSave, sectionMiscellaneous, "save the buffer, or the filtered lines, to a file", []string{"s"}, (*Pager).startSaving},
+		{actionPipe, sectionMiscellaneous, "pipe all lines, the visible lines, the lines from a mark, or the filtered lines into a shell command", []string{"|"}, (*Pager).startPiping},
+		{actionShellCommand, sectionMiscellaneous, "run a shell command. '%' is replaced with the current file name, and the current line number is in the $MOOR_LINE environment variable", []string{"!"}, (*Pager).startShellEscape},
+		{actionHelp, sectionMiscellaneous, "show this help text", []string{"h"}, (*Pager).showHelp},
+		{actionListFiles, sectionMiscellaneous, "list the open files, type to narrow the list down and press RETURN to switch to a file", []string{"B"}, (*Pager).startPickingFile},
+		{actionSelectLines, sectionMiscellaneous, "select lines to copy to the clipboard, or drag with the mouse to select", []string{"L"}, (*Pager).startSelecting},
+		{actionCopyLine, sectionMiscellaneous, "copy the current line to the clipboard", []string{"Y"}, (*Pager).copyCurrentLine},
+		{actionToggleCursor, sectionMiscellaneous, "toggle showing a cursor line, which the up and down keys move. Copying, marks, editing and piping start at the cursor line", []string{"C"}, (*Pager).toggleCursor},
+		{actionCommand, sectionMiscellaneous, "type a command, see Commands below", []string{":"}, func(p *Pager) {
+			p.mode = NewPagerModeColonCommand(p)
+			p.setTargetLine(nil)
+		}},
+
+		// '\x10' = CTRL-p, should scroll up one line.
+		// Ref: https://github.com/walles/moor/issues/107#issuecomment-1328354080
+		{actionScrollUp, sectionMovingAround, "move up one line", []string{"UP", "k", "y", "CTRL-p"}, func(p *Pager) {
+			if p.cursor != nil {
+				p.moveCursor(-1)
+				p.handleScrolledUp()
+				return
+			}
+
+			// Clipping is done in _Redraw()
+			p.scrollPosition = p.scrollPosition.PreviousLine(1)
+			p.handleScrolledUp()
+		}},
+
+		// '\x0e' = CTRL-n, should scroll down one line.
+		// Ref: https://github.com/walles/moor/issues/107#issuecomment-1328354080
+		{actionScrollDown, sectionMovingAround, "move down one line", []string{"DOWN", "RETURN", "j", "e", "CTRL-n"}, func(p *Pager) {
+			if p.cursor != nil {
+				p.moveCursor(1)
+				p.handleScrolledDown()
+				return
+			}
+
+			// Clipping is done in _Redraw()
+			p.scrollPosition = p.scrollPosition.NextLine(1)
+			p.handleScrolledDown()
+		}},
+
+		{actionScrollLeft, sectionMovingAround, "scroll left, or show line numbers when already at the left edge", []string{"LEFT"}, func(p *Pager) {
+			p.moveRight(-p.SideScrollAmount)
+		}},
+		{actionScrollRight, sectionMovingAround, "scroll right, hiding line numbers first", []string{"RIGHT"}, func(p *Pager) {
+			p.moveRight(p.SideScrollAmount)
+		}},
+		{actionScrollLeftOne, sectionMovingAround, "scroll left one column", []string{"ALT-LEFT"}, func(p *Pager) {
+			p.moveRight(-1)
+		}},
+		{actionScrollRightOne, sectionMovingAround, "scroll right one column", []string{"ALT-RIGHT"}, func(p *Pager) {
+			p.moveRight(1)
+		}},
+		{actionScrollToLeftEdge, sectionMovingAround, "move to the leftmost position", []string{"CTRL-a"}, func(p *Pager) {
+			p.leftColumnZeroBased = 0
+			if !p.showLineNumbers {
+				// Line numbers not visible, turn them on if the user wants them.
+				p.showLineNumbers = p.ShowLineNumbers
+			}
+		}},
+
+		// CTRL-b and CTRL-f should work like just 'b' and 'f'.
+		// Ref: https://github.com/walles/moor/issues/107
+		{actionPageUp, sectionMovingAround, "move up one page", []string{"PAGEUP", "b", "CTRL-b"}, func(p *Pager) {
+			p.scrollPosition = p.scrollPosition.PreviousLine(p.visibleHeight())
+			p.handleScrolledUp()
+		}},
+		{actionPageDown, sectionMovingAround, "move down one page", []string{"PAGEDOWN", "f", "SPACE", "CTRL-f"}, func(p *Pager) {
+			p.scrollPosition = p.scrollPosition.NextLine(p.visibleHeight())
+			p.handleScrolledDown()
+		}},
+
+		// CTRL-u and CTRL-d should work like just 'u' and 'd'.
+		// Ref: https://github.com/walles/moor/issues/90
+		{actionHalfPageUp, sectionMovingAround, "move up half a page", []string{"u", "CTRL-u"}, func(p *Pager) {
+			p.scrollPosition = p.scrollPosition.PreviousLine(p.visibleHeight() / 2)
+			p.handleScrolledUp()
+		}},
+		{actionHalfPageDown, sectionMovingAround, "move down half a page", []string{"d", "CTRL-d"}, func(p *Pager) {
+			p.scrollPosition = p.scrollPosition.NextLine(p.visibleHeight() / 2)
+			p.handleScrolledDown()
+		}},
+
+		{actionGoToStart, sectionMovingAround, "go to the start of the document", []string{"HOME", "<"}, func(p *Pager) {
+			p.recordJump()
+			p.scrollToStart()
+		}},
+		{actionGoToEnd, sectionMovingAround, "go to the end of the document", []string{"END", ">", "G"}, func(p *Pager) {
+			p.recordJump()
+			p.scrollToEnd()
+		}},
+		{actionGoToLine, sectionMovingAround, "go to a specific line number, press twice to go to the start of the document", []string{"g"}, func(p *Pager) {
+			p.mode = NewPagerModeGotoLine(p)
+			p.setTargetLine(nil)
+		}},
+		{actionSetMark, sectionMovingAround, "set a mark, you will be asked for a letter to label it with", []string{"m"}, func(p *Pager) {
+			p.mode = PagerModeMark{pager: p}
+			p.setTargetLine(nil)
+		}},
+		{actionJumpToMark, sectionMovingAround, "jump to a mark", []string{"'"}, func(p *Pager) {
+			p.mode = PagerModeJumpToMark{pager: p}
+			p.setTargetLine(nil)
+		}},
+		{actionJumpBack, sectionMovingAround, "go back to where you were before jumping to a search hit, a line, a mark, the start or the end, or another file", []string{"CTRL-o"}, (*Pager).jumpBack},
+		{actionJumpForward, sectionMovingAround, "go forward again after going back, CTRL-i is the same as TAB", []string{"CTRL-i"}, (*Pager).jumpForward},
+
+		{actionSearch, sectionSearching, "start searching, then type what you want to find", []string{"/"}, func(p *Pager) {
+			p.startSearch(SearchDirectionForward)
+		}},
+		{actionSearchBackwards, sectionSearching, "search backwards", []string{"?"}, func(p *Pager) {
+			p.startSearch(SearchDirectionBackward)
+		}},
+
+		// Also used by pagermode-not-found.go
+		{actionSearchNext, sectionSearching, "find the next hit", []string{"n"}, (*Pager).scrollToNextSearchHit},
+		{actionSearchPrevious, sectionSearching, "find the previous hit", []string{"p", "N"}, (*Pager).scrollToPreviousSearchHit},
+
+		{actionListHits, sectionSearching, "list all hits below the document, use the arrow keys to view each one in context", []string{"o"}, (*Pager).startOccur},
+		{actionAddHighlight, sectionSearching, "keep highlighting the current search in its own color", []string{"+"}, (*Pager).addSearchAsHighlight},
+		{actionListHighlights, sectionSearching, "list and remove highlights", []string{"-"}, func(p *Pager) {
+			if len(p.highlights) == 0 {
+				p.mode = &PagerModeInfo{Pager: p, Text: "No highlights, search for something and press " + p.keymap.describeFirstKey(actionAddHighlight) + " to add one"}
+			} else {
+				p.mode = PagerModeHighlights{pager: p}
+			}
+		}},
+
+		// Filtering the help text is not supported. Feel free to work on that
+		// if you feel that's time well spent.
+		{actionFilter, sectionFiltering, "start filtering, or add another filter to narrow down the view further", []string{"&"}, func(p *Pager) {
+			if !p.isShowingHelp {
+				p.startFiltering()
+			}
+		}},
+		{actionPopFilter, sectionFiltering, "remove the last filter", []string{"BACKSPACE"}, func(p *Pager) {
+			if !p.isShowingHelp {
+				p.popFilter()
+			}
+		}},
+		{actionFilterContext, sectionFiltering, "type how many lines to show around each match, either as one number or as BEFORE,AFTER", []string{"c"}, func(p *Pager) {
+			if !p.isShowingHelp {
+				p.mode = NewPagerModeFilterContext(p)
+			}
+		}},
+
+		{actionSplitBelow, sectionPanes, "split the screen, with a new pane below", []string{"S"}, func(p *Pager) {
+			p.split(false)
+		}},
+		{actionSplitRight, sectionPanes, "split the screen, with a new pane to the right", []string{"V"}, func(p *Pager) {
+			p.split(true)
+		}},
+		{actionNextPane, sectionPanes, "move focus to the next pane", []string{"CTRL-w"}, (*Pager).focusNextPane},
+		{actionClosePane, sectionPanes, "close the focused pane", []string{"X"}, (*Pager).closePane},
+
+		{actionNextChange, sectionDiff, "go to the next change", []string{"]"}, (*Pager).scrollToNextChange},
+		{actionPreviousChange, sectionDiff, "go to the previous change", []string{"["}, (*Pager).scrollToPreviousChange},
+	}
+}
+
+// Returns nil if there is no such action
+func findAction(name action) *actionInfo {
+	for i := range allActions {
+		if allActions[i].name == name {
+			return &allActions[i]
+		}
+	}
+	return nil
+}
+
+// Run the action, returns false if there is no such action
+func (p *Pager) runAction(name action) bool {
+	info := findAction(name)
+	if info == nil {
+		return false
+	}
+
+	info.run(p)
+	return true
+}
+
+func (p *Pager) startSearch(direction SearchDirection) {
+	p.mode = NewPagerModeSearch(p, direction, p.scrollPosition)
+	p.search.Clear()
+
+	// Searchers want to scan the whole file, start reading as much as we can
+	reallyHigh := linemetadata.IndexMax()
+	p.setTargetLine(&reallyHigh)
+}
+
+func (p *Pager) scrollToStart() {
+	p.scrollPosition = newScrollPosition("Pager scroll position")
+	p.handleScrolledUp()
+}
+
+func (p *Pager) toggleWrap() {
+	p.WrapLongLines = !p.WrapLongLines
+	if p.WrapLongLines {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Word wrapping enabled"}
+	} else {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Word wrapping disabled"}
+	}
+}
+
+func (p *Pager) showHelp() {
+	if p.isShowingHelp {
+		return
+	}
+
+	p.preHelpState = &_PreHelpState{
+		scrollPosition:      p.scrollPosition,
+		leftColumnZeroBased: p.leftColumnZeroBased,
+		targetLine:          p.TargetLine,
+	}
+	p.scrollPosition = newScrollPosition("Pager scroll position")
+	p.leftColumnZeroBased = 0
+	p.setTargetLine(nil)
+	p.isShowingHelp = true
+}
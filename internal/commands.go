@@ -0,0 +1,389 @@
+// The commands available on the ':' command line
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/internal/textstyles"
+)
+
+type colonCommand struct {
+	// The first name is the one shown in the help text, the others are
+	// shorthands
+	names []string
+
+	// Shown after the names in the help text, like "<file>"
+	args string
+
+	// Shown after the names and args in the help text
+	description string
+
+	// Completions for the argument, or nil if there are none
+	complete func(p *Pager, prefix string) []string
+
+	// Force is true if the command name ends with a '!'. Errors are shown to
+	// the user.
+	run func(p *Pager, arg string, force bool) error
+}
+
+// All commands, in the order they are presented in the help text
+var colonCommands = []colonCommand{
+	{[]string{"edit", "e"}, "<file>", "open another file", completePath, func(p *Pager, arg string, _ bool) error {
+		return p.openFile(arg)
+	}},
+	{[]string{"close", "d"}, "", "close the current file", nil, func(p *Pager, _ string, _ bool) error {
+		return p.closeCurrentFile()
+	}},
+	{[]string{"write", "w"}, "<file>", "save the buffer, or the filtered lines, to a file. Use ':w!' to overwrite an existing file", completePath, func(p *Pager, arg string, force bool) error {
+		if arg == "" {
+			return errors.New("Type the name of the file to save to, like ':w file.txt'")
+		}
+		p.save(arg, saveFormatFiltered, force)
+		return nil
+	}},
+	{[]string{"files", "ls"}, "", "list the open files, and switch to one of them", nil, func(p *Pager, _ string, _ bool) error {
+		p.startPickingFile()
+		return nil
+	}},
+	{[]string{"split"}, "[file]", "split the screen, with a new pane below, optionally opening a file in it", completePath, func(p *Pager, arg string, _ bool) error {
+		return p.splitAndOpen(false, arg)
+	}},
+	{[]string{"vsplit"}, "[file]", "split the screen, with a new pane to the right, optionally opening a file in it", completePath, func(p *Pager, arg string, _ bool) error {
+		return p.splitAndOpen(true, arg)
+	}},
+	{[]string{"only"}, "", "close all panes except for the focused one", nil, func(p *Pager, _ string, _ bool) error {
+		p.closeOtherPanes()
+		return nil
+	}},
+	{[]string{"next", "n"}, "", "go to the next file", nil, func(p *Pager, _ string, _ bool) error {
+		p.nextFile()
+		return nil
+	}},
+	{[]string{"previous", "p"}, "", "go to the previous file", nil, func(p *Pager, _ string, _ bool) error {
+		p.previousFile()
+		return nil
+	}},
+	{[]string{"first", "x"}, "", "go to the first file", nil, func(p *Pager, _ string, _ bool) error {
+		p.firstFile()
+		return nil
+	}},
+	{[]string{"search-all", "s"}, "", "see how many search hits each file has", nil, func(p *Pager, _ string, _ bool) error {
+		p.searchAllFiles()
+		return nil
+	}},
+	{[]string{"goto"}, "<line>", "go to a line number, or to a percentage like '50%'", nil, func(p *Pager, arg string, _ bool) error {
+		return p.gotoLineOrPercentage(arg)
+	}},
+	{[]string{"mark"}, "<letter>", "set a mark at the current position", nil, func(p *Pager, arg string, _ bool) error {
+		if utf8.RuneCountInString(arg) != 1 {
+			return errors.New("Type one letter to label the mark with, like ':mark a'")
+		}
+		mark, _ := utf8.DecodeRuneInString(arg)
+		p.bookmarks[mark] = p.markPosition()
+		return nil
+	}},
+	{[]string{"set"}, "<option>", "change a setting: [no]wrap, [no]linenumbers, [no]statusbar, statusbar=inverse|plain|bold, [no]cursor or tabsize=N", completeSetOption, func(p *Pager, arg string, _ bool) error {
+		return p.setOption(arg)
+	}},
+	{[]string{"lang"}, "<language>", "change the language used for highlighting the current file", completeLanguage, func(p *Pager, arg string, _ bool) error {
+		return p.setLanguage(arg)
+	}},
+	{[]string{"quit", "q"}, "", "quit", nil, func(p *Pager, _ string, _ bool) error {
+		p.Quit()
+		return nil
+	}},
+}
+
+// For completing ':set'
+var setOptions = []string{
+	"wrap", "nowrap",
+	"linenumbers", "nolinenumbers",
+	"statusbar", "nostatusbar",
+	"statusbar=inverse", "statusbar=plain", "statusbar=bold",
+	"cursor", "nocursor",
+	"tabsize=",
+}
+
+func findColonCommand(name string) *colonCommand {
+	for i, command := range colonCommands {
+		if slices.Contains(command.names, name) {
+			return &colonCommands[i]
+		}
+	}
+	return nil
+}
+
+// Run a command line like "set wrap" or "e ../file.txt"
+func (p *Pager) runColonCommand(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+
+	if _, err := parseGotoTarget(line); err == nil {
+		// ":50" or ":50%"
+		return p.gotoLineOrPercentage(line)
+	}
+
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	name, force := strings.CutSuffix(name, "!")
+
+	command := findColonCommand(name)
+	if command == nil {
+		return fmt.Errorf("Unknown command: %s", name)
+	}
+
+	log.Debugf("Running command %q with argument %q", command.names[0], arg)
+	return command.run(p, arg, force)
+}
+
+// Completions for the whole command line
+func (p *Pager) completeColonCommand(line string) []string {
+	name, arg, hasArg := strings.Cut(line, " ")
+	if !hasArg {
+		completions := []string{}
+		for _, command := range colonCommands {
+			for _, commandName := range command.names {
+				if strings.HasPrefix(commandName, name) {
+					completions = append(completions, commandName+" ")
+				}
+			}
+		}
+		return completions
+	}
+
+	command := findColonCommand(strings.TrimSuffix(name, "!"))
+	if command == nil || command.complete == nil {
+		return nil
+	}
+
+	completions := []string{}
+	for _, completion := range command.complete(p, strings.TrimLeft(arg, " ")) {
+		completions = append(completions, name+" "+completion)
+	}
+	return completions
+}
+
+// Complete file and directory names. Directories get a trailing slash so that
+// completing can continue inside of them.
+func completePath(_ *Pager, prefix string) []string {
+	if os.Getenv("LESSSECURE") == "1" {
+		return nil
+	}
+
+	dir, base := filepath.Split(prefix)
+	listDir := expandHomeDir(dir)
+	if listDir == "" {
+		listDir = "."
+	}
+
+	entries, err := os.ReadDir(listDir)
+	if err != nil {
+		log.Debugf("Not completing %q: %v", prefix, err)
+		return nil
+	}
+
+	completions := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) {
+			continue
+		}
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			// Hidden, unless asked for
+			continue
+		}
+
+		completion := dir + name
+		if stat, err := os.Stat(filepath.Join(listDir, name)); err == nil && stat.IsDir() {
+			// Stat() rather than entry.IsDir() to follow symlinks
+			completion += string(filepath.Separator)
+		}
+		completions = append(completions, completion)
+	}
+	return completions
+}
+
+func completeSetOption(_ *Pager, prefix string) []string {
+	completions := []string{}
+	for _, option := range setOptions {
+		if strings.HasPrefix(option, prefix) {
+			completions = append(completions, option)
+		}
+	}
+	return completions
+}
+
+func completeLanguage(_ *Pager, prefix string) []string {
+	completions := []string{}
+	for _, name := range lexers.Names(false) {
+		if strings.HasPrefix(strings.ToLower(name), strings.ToLower(prefix)) {
+			completions = append(completions, name)
+		}
+	}
+	return completions
+}
+
+// A one based line number for "50", or a percentage of the line count for
+// "50%"
+type gotoTarget struct {
+	number    int
+	isPercent bool
+}
+
+func parseGotoTarget(text string) (gotoTarget, error) {
+	number, isPercent := strings.CutSuffix(text, "%")
+	value, err := strconv.Atoi(number)
+	if err != nil {
+		return gotoTarget{}, err
+	}
+	if value < 0 || (!isPercent && value < 1) || (isPercent && value > 100) {
+		return gotoTarget{}, fmt.Errorf("out of range: %s", text)
+	}
+
+	return gotoTarget{number: value, isPercent: isPercent}, nil
+}
+
+func (p *Pager) gotoLineOrPercentage(text string) error {
+	target, err := parseGotoTarget(text)
+	if err != nil {
+		return fmt.Errorf("Not a line number or a percentage: %q", text)
+	}
+
+	if target.isPercent {
+		lineCount := p.Reader().GetLineCount()
+		p.gotoIndex(linemetadata.IndexFromZeroBased(min(lineCount*target.number/100, max(lineCount-1, 0))))
+		return nil
+	}
+
+	p.gotoLine(linemetadata.IndexFromOneBased(max(target.number, 1)))
+	return nil
+}
+
+// Go to a line number in the current file
+func (p *Pager) gotoLine(lineIndex linemetadata.Index) {
+	p.gotoIndex(p.viewIndexOfLine(lineIndex))
+}
+
+// Go to an index into p.Reader()
+func (p *Pager) gotoIndex(targetIndex linemetadata.Index) {
+	p.recordJump()
+	p.scrollPosition = NewScrollPositionFromIndex(
+		targetIndex,
+		"onGotoLineKey",
+	)
+	p.setTargetLine(&targetIndex)
+	if p.cursor != nil {
+		p.cursor = &targetIndex
+	}
+}
+
+func (p *Pager) setOption(option string) error {
+	switch option {
+	case "wrap":
+		p.WrapLongLines = true
+	case "nowrap":
+		p.WrapLongLines = false
+	case "linenumbers":
+		p.ShowLineNumbers = true
+		p.showLineNumbers = true
+	case "nolinenumbers":
+		p.ShowLineNumbers = false
+		p.showLineNumbers = false
+	case "statusbar":
+		p.ShowStatusBar = true
+	case "nostatusbar":
+		p.ShowStatusBar = false
+	case "cursor":
+		if p.cursor == nil {
+			p.enableCursor()
+		}
+	case "nocursor":
+		p.cursor = nil
+	case "statusbar=inverse":
+		p.setStatusBarStyle(STATUSBAR_STYLE_INVERSE)
+	case "statusbar=plain":
+		p.setStatusBarStyle(STATUSBAR_STYLE_PLAIN)
+	case "statusbar=bold":
+		p.setStatusBarStyle(STATUSBAR_STYLE_BOLD)
+	default:
+		tabSizeString, isTabSize := strings.CutPrefix(option, "tabsize=")
+		if !isTabSize {
+			return fmt.Errorf("Unknown option %q, try one of: %s", option, strings.Join(setOptions, ", "))
+		}
+
+		tabSize, err := strconv.Atoi(tabSizeString)
+		if err != nil || tabSize < 1 {
+			return fmt.Errorf("Tab size must be a positive number, not %q", tabSizeString)
+		}
+		p.TabSize = tabSize
+		textstyles.TabSize = tabSize
+	}
+
+	return nil
+}
+
+func (p *Pager) setStatusBarStyle(style StatusBarOption) {
+	p.StatusBarStyle = style
+	styleUI(p.screen.TerminalBackground(), p.chromaStyle, p.chromaFormatter, p.StatusBarStyle, p.WithTerminalFg, p.WithSearchHitLineBackground)
+}
+
+func (p *Pager) setLanguage(language string) error {
+	if language == "" {
+		return errors.New("Type a language to highlight as, like ':lang go'")
+	}
+
+	lexer := lexers.MatchMimeType(language)
+	if lexer == nil {
+		lexer = lexers.Get(language)
+	}
+	if lexer == nil {
+		return fmt.Errorf("Unknown language: %s", language)
+	}
+
+	p.readerLock.Lock()
+	current := p.readers[p.currentReader]
+	p.readerLock.Unlock()
+
+	if !current.SetLexer(lexer) {
+		return errors.New("Only files can be highlighted again, not streams")
+	}
+
+	p.stayAtCurrentLineAcrossReload()
+	return nil
+}
+
+// Open a file and switch to it
+func (p *Pager) openFile(path string) error {
+	if os.Getenv("LESSSECURE") == "1" {
+		return errors.New("Not opening files since LESSSECURE=1 is set in the environment")
+	}
+
+	if path == "" {
+		return errors.New("Type the name of the file to open, like ':e file.txt'")
+	}
+
+	path = expandHomeDir(path)
+	formatter, options, settings := p.newReaderOptions(path)
+	r, err := reader.NewFromFilename(path, formatter, options)
+	if err != nil {
+		return err
+	}
+
+	p.SetFileSettings(r, settings)
+	p.addReader(r)
+	return nil
+}
@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+// A pager showing the numbers 1 to 100
+func newCommandTestPager(t *testing.T) *Pager {
+	return newTestPager(t, twin.NewFakeScreen(40, 10), reader.NewFromTextForTesting("test", numberedLines("", 100)))
+}
+
+func typeCommand(pager *Pager, command string) {
+	pager.mode.onRune(':')
+	for _, char := range command {
+		pager.mode.onRune(char)
+	}
+	pager.mode.onKey(twin.KeyEnter)
+}
+
+func TestColonCommandSet(t *testing.T) {
+	pager := newCommandTestPager(t)
+
+	typeCommand(pager, "set wrap")
+	assert.Assert(t, pager.WrapLongLines)
+	assert.Equal(t, pager.mode, PagerModeViewing{pager: pager})
+
+	typeCommand(pager, "set tabsize=3")
+	assert.Equal(t, pager.TabSize, 3)
+
+	typeCommand(pager, "set nolinenumbers")
+	assert.Assert(t, !pager.ShowLineNumbers)
+
+	typeCommand(pager, "set tabsize=0")
+	assert.Equal(t, pager.mode.(*PagerModeInfo).Text, `Tab size must be a positive number, not "0"`)
+	assert.Equal(t, pager.TabSize, 3)
+}
+
+func TestColonCommandUnknown(t *testing.T) {
+	pager := newCommandTestPager(t)
+
+	typeCommand(pager, "fly away")
+	assert.Equal(t, pager.mode.(*PagerModeInfo).Text, "Unknown command: fly")
+	assert.DeepEqual(t, pager.commandHistory.entries, []string{"fly away"})
+}
+
+func TestColonCommandGoto(t *testing.T) {
+	pager := newCommandTestPager(t)
+
+	typeCommand(pager, "goto 5")
+	assert.Equal(t, *pager.TargetLine, linemetadata.IndexFromOneBased(5))
+
+	typeCommand(pager, "50%")
+	assert.Equal(t, *pager.TargetLine, linemetadata.IndexFromZeroBased(50))
+
+	typeCommand(pager, "100%")
+	assert.Equal(t, *pager.TargetLine, linemetadata.IndexFromZeroBased(99))
+
+	typeCommand(pager, "7")
+	assert.Equal(t, *pager.TargetLine, linemetadata.IndexFromOneBased(7))
+
+	typeCommand(pager, "mark a")
+	assert.Equal(t, len(pager.bookmarks), 1)
+}
+
+func TestColonCommandCompletion(t *testing.T) {
+	pager := newCommandTestPager(t)
+
+	pager.mode.onRune(':')
+	mode := pager.mode.(*PagerModeColonCommand)
+
+	mode.onRune('s')
+	mode.onRune('e')
+	mode.onRune('t')
+	mode.onRune('\t')
+	assert.Equal(t, mode.inputBox.text, "set ")
+
+	mode.onRune('n')
+	mode.onRune('o')
+	mode.onRune('w')
+	mode.onRune('\t')
+	assert.Equal(t, mode.inputBox.text, "set nowrap")
+
+	// With many candidates, TAB cycles through them
+	mode.inputBox.setText("set no")
+	mode.onRune('\t')
+	assert.Equal(t, mode.inputBox.text, "set nowrap")
+	mode.onRune('\t')
+	assert.Equal(t, mode.inputBox.text, "set nolinenumbers")
+	mode.onRune('\t')
+	assert.Equal(t, mode.inputBox.text, "set nostatusbar")
+	mode.onRune('\t')
+	assert.Equal(t, mode.inputBox.text, "set nocursor")
+	mode.onRune('\t')
+	assert.Equal(t, mode.inputBox.text, "set nowrap")
+}
+
+func TestColonCommandHistory(t *testing.T) {
+	pager := newCommandTestPager(t)
+	pager.commandHistory = &SearchHistory{entries: []string{"set wrap", "goto 5"}}
+
+	pager.mode.onRune(':')
+	mode := pager.mode.(*PagerModeColonCommand)
+
+	mode.onRune('x')
+	mode.onKey(twin.KeyUp)
+	assert.Equal(t, mode.inputBox.text, "goto 5")
+	mode.onKey(twin.KeyUp)
+	assert.Equal(t, mode.inputBox.text, "set wrap")
+	mode.onKey(twin.KeyDown)
+	mode.onKey(twin.KeyDown)
+	assert.Equal(t, mode.inputBox.text, "x", "Back to what the user typed")
+}
+
+func TestColonCommandEdit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "other.txt")
+	assert.NilError(t, os.WriteFile(path, []byte("hello\n"), 0o600))
+
+	pager := newCommandTestPager(t)
+
+	typeCommand(pager, "e "+path)
+	assert.Equal(t, len(pager.readers), 2)
+	assert.Equal(t, pager.currentReader, 1)
+	assert.Equal(t, *pager.readers[1].DisplayName, "other.txt")
+
+	typeCommand(pager, "p")
+	assert.Equal(t, pager.currentReader, 0)
+
+	typeCommand(pager, "e "+path+".missing")
+	_, isInfo := pager.mode.(*PagerModeInfo)
+	assert.Assert(t, isInfo)
+	assert.Equal(t, len(pager.readers), 2)
+}
+
+// Files opened while paging should get the same options as the ones we
+// started with
+func TestColonCommandEditUsesOptionsForFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "other.md")
+	assert.NilError(t, os.WriteFile(path, []byte("hello\n"), 0o600))
+
+	pager := newCommandTestPager(t)
+	wrap := true
+	requestedName := ""
+	pager.OptionsForFile = func(fileName string) (reader.ReaderOptions, FileSettings) {
+		requestedName = fileName
+		return reader.ReaderOptions{}, FileSettings{WrapLongLines: &wrap}
+	}
+
+	typeCommand(pager, "e "+path)
+	assert.Equal(t, requestedName, path)
+	assert.Equal(t, pager.currentReader, 1)
+	assert.Assert(t, pager.WrapLongLines)
+
+	typeCommand(pager, "p")
+	assert.Assert(t, !pager.WrapLongLines)
+}
+
+func TestCompletePath(t *testing.T) {
+	dir := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "app.log"), []byte{}, 0o600))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "app.log.1.gz"), []byte{}, 0o600))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, ".hidden"), []byte{}, 0o600))
+	assert.NilError(t, os.Mkdir(filepath.Join(dir, "archive"), 0o700))
+
+	sep := string(filepath.Separator)
+	assert.DeepEqual(t, completePath(nil, dir+sep+"app"), []string{
+		dir + sep + "app.log",
+		dir + sep + "app.log.1.gz",
+	})
+	assert.DeepEqual(t, completePath(nil, dir+sep+"ar"), []string{dir + sep + "archive" + sep})
+	assert.DeepEqual(t, completePath(nil, dir+sep+"."), []string{dir + sep + ".hidden"})
+	assert.Equal(t, len(completePath(nil, dir+sep)), 3, "Hidden files should be left out")
+
+	pager := newCommandTestPager(t)
+	pager.mode.onRune(':')
+	mode := pager.mode.(*PagerModeColonCommand)
+	mode.inputBox.setText("e " + dir + sep + "app.log.")
+	mode.onRune('\t')
+	assert.Equal(t, mode.inputBox.text, "e "+dir+sep+"app.log.1.gz")
+}
@@ -0,0 +1,186 @@
+// Package config reads moor's TOML config file.
+//
+// Ref: https://toml.io/
+//
+// Settings are key = value pairs, at the top of the file or in one level of
+// [sections]. Values are strings, numbers, booleans or arrays of those.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/BurntSushi/toml"
+	"github.com/adrg/xdg"
+)
+
+// A Value is what's to the right of the = sign. Scalar values are stored as a
+// one element list.
+type Value struct {
+	Strings []string
+	IsList  bool
+}
+
+// String returns a scalar value, or the last element of a list
+func (v Value) String() string {
+	if len(v.Strings) == 0 {
+		return ""
+	}
+	return v.Strings[len(v.Strings)-1]
+}
+
+type Section struct {
+	Name string
+
+	// In the order they appear in the file
+	Keys []string
+
+	Values map[string]Value
+}
+
+type File struct {
+	// Empty means no file was read
+	Path string
+
+	// The unnamed section at the top of the file has the empty name
+	Sections map[string]*Section
+
+	// Named sections, in the order they appear in the file
+	SectionNames []string
+}
+
+// DefaultPath returns $XDG_CONFIG_HOME/moor/config.toml
+func DefaultPath() string {
+	return filepath.Join(xdg.ConfigHome, "moor", "config.toml")
+}
+
+// Load reads a config file. A missing file gives an empty config without any
+// error.
+func Load(path string) (*File, error) {
+	bytes, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &File{Sections: map[string]*Section{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := Parse(string(bytes))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	file.Path = path
+	return file, nil
+}
+
+// Parse parses config file contents
+func Parse(contents string) (*File, error) {
+	var data map[string]any
+	metadata, err := toml.Decode(contents, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &File{Sections: map[string]*Section{}}
+	for _, key := range metadata.Keys() {
+		switch len(key) {
+		case 1:
+			tomlType := metadata.Type(key...)
+			if tomlType == "Hash" {
+				// A [section], or an inline table
+				file.section(key[0])
+				continue
+			}
+			if tomlType == "ArrayHash" {
+				return nil, fmt.Errorf("[[%s]]: arrays of tables are not supported, use [%s]", key[0], key[0])
+			}
+
+			err = file.section("").set(key[0], data[key[0]])
+
+		case 2:
+			table, ok := data[key[0]].(map[string]any)
+			if !ok {
+				// Can't happen, TOML makes sure there's a table here
+				return nil, fmt.Errorf("%s: %s is not a table", key, key[0])
+			}
+			err = file.section(key[0]).set(key[1], table[key[1]])
+
+		default:
+			return nil, fmt.Errorf("%s: only one level of [sections] is supported", key)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	return file, nil
+}
+
+// Add a key = value pair
+func (s *Section) set(key string, value any) error {
+	if list, isList := value.([]any); isList {
+		result := Value{Strings: []string{}, IsList: true}
+		for _, element := range list {
+			text, err := scalarToString(element)
+			if err != nil {
+				return err
+			}
+			result.Strings = append(result.Strings, text)
+		}
+
+		s.Keys = append(s.Keys, key)
+		s.Values[key] = result
+		return nil
+	}
+
+	text, err := scalarToString(value)
+	if err != nil {
+		return err
+	}
+
+	s.Keys = append(s.Keys, key)
+	s.Values[key] = Value{Strings: []string{text}}
+	return nil
+}
+
+func scalarToString(value any) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case int64:
+		return strconv.FormatInt(value, 10), nil
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64), nil
+	case map[string]any, []map[string]any:
+		return "", errors.New("tables are only supported as [sections]")
+	case []any:
+		return "", errors.New("lists can't contain lists")
+	default:
+		return "", fmt.Errorf("unsupported value: %v", value)
+	}
+}
+
+// Section returns the named section, or nil if there is no such section
+func (f *File) Section(name string) *Section {
+	return f.Sections[name]
+}
+
+// Get the named section, creating it if needed
+func (f *File) section(name string) *Section {
+	section, found := f.Sections[name]
+	if !found {
+		section = &Section{Name: name, Values: map[string]Value{}}
+		f.Sections[name] = section
+		if name != "" {
+			f.SectionNames = append(f.SectionNames, name)
+		}
+	}
+	return section
+}
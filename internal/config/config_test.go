@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParse(t *testing.T) {
+	file, err := Parse(`
+# A comment
+wrap = true
+tab-size = 4 # Trailing comment
+
+[keys]
+quit = ["q", "#", 'ESC']
+"search-next" = "\"n\""
+`)
+	assert.NilError(t, err)
+
+	top := file.Section("")
+	assert.DeepEqual(t, top.Keys, []string{"wrap", "tab-size"})
+	assert.Equal(t, top.Values["wrap"].String(), "true")
+	assert.Equal(t, top.Values["tab-size"].String(), "4")
+
+	keys := file.Section("keys")
+	assert.DeepEqual(t, keys.Values["quit"].Strings, []string{"q", "#", "ESC"})
+	assert.Assert(t, keys.Values["quit"].IsList)
+	assert.Equal(t, keys.Values["search-next"].String(), `"n"`)
+	assert.Assert(t, !keys.Values["search-next"].IsList)
+
+	assert.Assert(t, file.Section("nonexistent") == nil)
+}
+
+func TestParseSectionOrder(t *testing.T) {
+	file, err := Parse(`
+wrap = false
+
+["*.md"]
+wrap = true
+
+[man]
+no-linenumbers = true
+`)
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, file.SectionNames, []string{"*.md", "man"})
+	assert.Equal(t, file.Section("*.md").Values["wrap"].String(), "true")
+}
+
+// Valid TOML that used to be rejected
+func TestParseTOML(t *testing.T) {
+	file, err := Parse(`
+keys.quit = "x"
+"*.md" = { wrap = true }
+
+[keys]
+search-next = [
+  "n", # Trailing comment
+  "enter",
+]
+`)
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, file.SectionNames, []string{"keys", "*.md"})
+	keys := file.Section("keys")
+	assert.DeepEqual(t, keys.Keys, []string{"quit", "search-next"})
+	assert.Equal(t, keys.Values["quit"].String(), "x")
+	assert.DeepEqual(t, keys.Values["search-next"].Strings, []string{"n", "enter"})
+	assert.Equal(t, file.Section("*.md").Values["wrap"].String(), "true")
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse("[keys\n")
+	assert.ErrorContains(t, err, "line 2")
+
+	_, err = Parse("a = 1\na = 2\n")
+	assert.ErrorContains(t, err, "Key 'a' has already been defined")
+
+	_, err = Parse("[[keys]]\nquit = \"x\"\n")
+	assert.Error(t, err, "[[keys]]: arrays of tables are not supported, use [keys]")
+
+	_, err = Parse("keys.quit.now = \"x\"\n")
+	assert.Error(t, err, "keys.quit.now: only one level of [sections] is supported")
+
+	_, err = Parse("quit = [[\"q\"]]\n")
+	assert.Error(t, err, "quit: lists can't contain lists")
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	file, err := Load(filepath.Join(t.TempDir(), "nonexistent.toml"))
+	assert.NilError(t, err)
+	assert.Equal(t, file.Path, "")
+	assert.Equal(t, len(file.Sections), 0)
+}
+
+func TestLoadReportsPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	assert.NilError(t, os.WriteFile(path, []byte("oops\n"), 0o600))
+
+	_, err := Load(path)
+	assert.ErrorContains(t, err, path+": toml: line 1")
+}
@@ -0,0 +1,137 @@
+// An optional cursor line, moved by the keys that would otherwise scroll one
+// line. The view only scrolls when the cursor reaches the top or the bottom of
+// the screen.
+
+package internal
+
+import (
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+)
+
+func (p *Pager) toggleCursor() {
+	if p.cursor != nil {
+		p.cursor = nil
+		p.mode = &PagerModeInfo{Pager: p, Text: "Cursor line disabled"}
+		return
+	}
+
+	p.enableCursor()
+	p.mode = &PagerModeInfo{Pager: p, Text: "Cursor line enabled"}
+}
+
+// Put the cursor on the top line of the screen
+func (p *Pager) enableCursor() {
+	top := linemetadata.Index{}
+	if p.lineIndex() != nil {
+		top = *p.lineIndex()
+	}
+	p.cursor = &top
+}
+
+// Move the cursor up or down, and scroll if it goes off screen
+func (p *Pager) moveCursor(delta int) {
+	lineCount := p.Reader().GetLineCount()
+	if lineCount == 0 {
+		return
+	}
+
+	moved := p.cursor.NonWrappingAdd(delta)
+	if !moved.IsWithinLength(lineCount) {
+		moved = *linemetadata.IndexFromLength(lineCount)
+	}
+	p.cursor = &moved
+
+	p.scrollToShowIndex(moved)
+}
+
+// After scrolling by other means than moving the cursor, the cursor follows
+// along to stay on screen
+func (p *Pager) keepCursorOnScreen() {
+	if p.cursor == nil {
+		return
+	}
+
+	top := p.lineIndex()
+	if top == nil {
+		// No lines
+		p.cursor = &linemetadata.Index{}
+		return
+	}
+
+	if p.cursor.IsBefore(*top) {
+		// Copy so that the cursor doesn't move with the scroll position
+		topCopy := *top
+		p.cursor = &topCopy
+		return
+	}
+
+	last := p.getLastVisibleLineIndex()
+	if last != nil && p.cursor.IsAfter(*last) {
+		p.cursor = last
+	}
+}
+
+// Which index into p.Reader() operations like copying or piping should start
+// at. This is the cursor line if there is one, otherwise the top line of the
+// screen. Nil if there are no lines.
+func (p *Pager) currentLineIndex() *linemetadata.Index {
+	if p.cursor != nil {
+		return p.cursor
+	}
+	return p.lineIndex()
+}
+
+// Separators between groups of filtered lines aren't part of the file. For
+// those, this returns the index of the line after, there always is one.
+func (p *Pager) skipSeparator(index linemetadata.Index) linemetadata.Index {
+	if p.filteredLineKind(index) == filteredLineSeparator {
+		return index.NonWrappingAdd(1)
+	}
+	return index
+}
+
+// The line the cursor is on, or nil if there is no cursor. If the cursor is on
+// a separator, this is the line after it.
+func (p *Pager) cursorLine() *reader.NumberedLine {
+	if p.cursor == nil {
+		return nil
+	}
+	return p.Reader().GetLine(p.skipSeparator(*p.cursor))
+}
+
+// Like ": 1234 lines  12%  line 148", with the percentage of the cursor line
+// rather than of the bottom of the screen
+func (p *Pager) cursorStatusText() string {
+	line := p.cursorLine()
+	if line == nil {
+		return ""
+	}
+
+	status := p.Reader().GetLines(line.Index, 1).StatusText
+	return status + "  line " + line.Number.Format()
+}
+
+// Marks remember the cursor line when there is one. Never a separator, since
+// that isn't part of the file.
+func (p *Pager) markPosition() scrollPosition {
+	if p.cursor != nil {
+		return NewScrollPositionFromIndex(p.skipSeparator(*p.cursor), "markPosition")
+	}
+
+	if top := p.lineIndex(); top != nil && p.filteredLineKind(*top) == filteredLineSeparator {
+		return NewScrollPositionFromIndex(p.skipSeparator(*top), "markPosition")
+	}
+	return p.scrollPosition
+}
+
+// Copy the current line to the clipboard
+func (p *Pager) copyCurrentLine() {
+	current := p.currentLineIndex()
+	if current == nil {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Nothing to copy"}
+		return
+	}
+
+	p.copyLines(*current, *current, false)
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func newCursorTestPager(t *testing.T) (*Pager, *twin.FakeScreen) {
+	screen := twin.NewFakeScreen(40, 4)
+	pager := newTestPager(t, screen, reader.NewFromTextForTesting("TestCursor", "a\nb\nc\nd\ne\nf"))
+
+	pager.mode.onRune('C')
+	assert.Equal(t, "Info", modeName(pager))
+	pager.mode = PagerModeViewing{pager: pager}
+
+	return pager, screen
+}
+
+func TestCursorMovesBeforeScrolling(t *testing.T) {
+	pager, screen := newCursorTestPager(t)
+
+	// Three lines visible, the cursor should move within them before
+	// scrolling starts
+	pager.mode.onRune('j')
+	pager.mode.onRune('j')
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "a")
+	assert.Equal(t, screen.GetRow(2)[0].Style.Background(), cursorLineBackground)
+	assert.Assert(t, strings.Contains(rowToString(screen.GetRow(3)), "50%  line 3"), rowToString(screen.GetRow(3)))
+
+	pager.mode.onRune('j')
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "b")
+	assert.Equal(t, *pager.cursor, linemetadata.IndexFromZeroBased(3))
+
+	// Moving back up shouldn't scroll until the cursor is at the top
+	pager.mode.onRune('k')
+	pager.mode.onRune('k')
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "b")
+	pager.mode.onRune('k')
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "a")
+}
+
+func TestCursorFollowsScrolling(t *testing.T) {
+	pager, _ := newCursorTestPager(t)
+
+	pager.mode.onKey(twin.KeyEnd)
+	pager.redraw("")
+	assert.Equal(t, *pager.cursor, linemetadata.IndexFromZeroBased(3), "Cursor should stay on screen")
+}
+
+func TestCursorLineOperations(t *testing.T) {
+	pager, screen := newCursorTestPager(t)
+	pager.mode.onRune('j')
+
+	pager.mode.onRune('Y')
+	assert.Equal(t, screen.Clipboard(), "b")
+
+	assert.Equal(t, pager.editorLineNumber().AsOneBased(), 2)
+
+	assert.Equal(t, len(pager.linesToPipe(pipeRangeFromHere, 0)), 5)
+
+	pager.mode.onRune('m')
+	pager.mode.onRune('x')
+	mark := pager.bookmarks['x']
+	assert.Equal(t, *mark.lineIndex(pager), linemetadata.IndexFromZeroBased(1))
+}
+
+// Separators between filtered groups of lines aren't part of the file, so the
+// cursor line and marks should use the line after them
+func TestCursorSkipsSeparators(t *testing.T) {
+	screen := twin.NewFakeScreen(40, 4)
+	pager := newTestPager(t, screen, reader.NewFromTextForTesting("TestCursor", "a\nb\nX1\nc\nd\ne\nf\nX2\ng"))
+	pager.filterHistory = &SearchHistory{} // Don't touch the user's history file
+	pager.filterContext = FilterContext{Before: 1, After: 1}
+	typeFilter(pager, "X")
+
+	separator := linemetadata.IndexFromZeroBased(3)
+	assert.Equal(t, pager.filteredLineKind(separator), filteredLineSeparator)
+
+	// Top line on the separator
+	pager.scrollPosition = NewScrollPositionFromIndex(separator, "TestCursorSkipsSeparators")
+	mark := pager.markPosition()
+	assert.Equal(t, *mark.lineIndex(pager), separator.NonWrappingAdd(1))
+
+	// Cursor on the separator
+	pager.mode.onRune('C')
+	pager.cursor = &separator
+	assert.Equal(t, pager.cursorLine().Plain(), "f")
+	mark = pager.markPosition()
+	assert.Equal(t, *mark.lineIndex(pager), separator.NonWrappingAdd(1))
+}
@@ -0,0 +1,542 @@
+// Line diffs of two files, for showing them side by side. See --diff.
+
+package internal
+
+import (
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+)
+
+type diffLineKind int
+
+const (
+	diffLineSame    diffLineKind = iota
+	diffLineChanged              // On both sides, but different
+	diffLineAdded                // Only on the right side
+	diffLineRemoved              // Only on the left side
+)
+
+// With more differences than this, we give up looking for common lines and
+// treat the rest as one big change. This caps the memory used by
+// longestCommonSubsequence() at a few tens of megabytes.
+const maxDiffEdits = 2000
+
+// One row of the side by side view. The line index is -1 on the side where a
+// line was added or removed.
+type diffRow struct {
+	left  int
+	right int
+	kind  diffLineKind
+}
+
+// Shown on the side where a line was added or removed, to keep the sides
+// aligned
+var diffFillerLine = reader.NewLine("")
+
+// Align the lines of two files into rows, with common lines side by side
+func diffLines(left []string, right []string) []diffRow {
+	// Common prefixes and suffixes are cheap to find, and keep the expensive
+	// part small
+	prefix := 0
+	for prefix < len(left) && prefix < len(right) && left[prefix] == right[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(left)-prefix && suffix < len(right)-prefix && left[len(left)-1-suffix] == right[len(right)-1-suffix] {
+		suffix++
+	}
+
+	rows := make([]diffRow, 0, max(len(left), len(right)))
+	for i := range prefix {
+		rows = append(rows, diffRow{left: i, right: i, kind: diffLineSame})
+	}
+
+	leftEnd := len(left) - suffix
+	rightEnd := len(right) - suffix
+	matches := longestCommonSubsequence(left[prefix:leftEnd], right[prefix:rightEnd])
+
+	leftIndex := prefix
+	rightIndex := prefix
+	for _, match := range matches {
+		rows = appendDiffHunk(rows, leftIndex, prefix+match[0], rightIndex, prefix+match[1])
+		rows = append(rows, diffRow{left: prefix + match[0], right: prefix + match[1], kind: diffLineSame})
+		leftIndex = prefix + match[0] + 1
+		rightIndex = prefix + match[1] + 1
+	}
+	rows = appendDiffHunk(rows, leftIndex, leftEnd, rightIndex, rightEnd)
+
+	for i := range suffix {
+		rows = append(rows, diffRow{left: leftEnd + i, right: rightEnd + i, kind: diffLineSame})
+	}
+
+	return rows
+}
+
+// Lines present on both sides are shown as changed, side by side. The rest are
+// shown as removed or added.
+func appendDiffHunk(rows []diffRow, leftStart int, leftEnd int, rightStart int, rightEnd int) []diffRow {
+	paired := min(leftEnd-leftStart, rightEnd-rightStart)
+	for i := range paired {
+		rows = append(rows, diffRow{left: leftStart + i, right: rightStart + i, kind: diffLineChanged})
+	}
+	for i := leftStart + paired; i < leftEnd; i++ {
+		rows = append(rows, diffRow{left: i, right: -1, kind: diffLineRemoved})
+	}
+	for i := rightStart + paired; i < rightEnd; i++ {
+		rows = append(rows, diffRow{left: -1, right: i, kind: diffLineAdded})
+	}
+	return rows
+}
+
+// Find the matching lines using Myers' O(ND) algorithm. Returns pairs of
+// indices into a and b, in order.
+//
+// Ref: http://www.xmailserver.org/diff2.pdf
+func longestCommonSubsequence(a []string, b []string) [][2]int {
+	n := len(a)
+	m := len(b)
+	maxEdits := min(n+m, maxDiffEdits)
+
+	// v[offset+k] is the furthest x reached on diagonal k
+	offset := maxEdits + 1
+	v := make([]int, 2*offset+1)
+
+	// What v looked like before each round, for finding our way back. Only
+	// the part used in each round is stored.
+	trace := [][]int{}
+
+	for d := 0; d <= maxEdits; d++ {
+		trace = append(trace, slices.Clone(v[offset-d-1:offset+d+2]))
+
+		for k := -d; k <= d; k += 2 {
+			x := v[offset+k-1] + 1 // Removing from a
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Adding from b
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrackCommonSubsequence(trace, n, m)
+			}
+		}
+	}
+
+	log.Debugf("More than %d differences, treating the rest as one change", maxDiffEdits)
+	return nil
+}
+
+func backtrackCommonSubsequence(trace [][]int, x int, y int) [][2]int {
+	matches := [][2]int{}
+	for d := len(trace) - 1; d >= 0; d-- {
+		// trace[d] starts at diagonal -d-1
+		v := func(k int) int {
+			return trace[d][k+d+1]
+		}
+
+		k := x - y
+		previousK := k - 1
+		if k == -d || (k != d && v(k-1) < v(k+1)) {
+			previousK = k + 1
+		}
+		previousX := v(previousK)
+		previousY := previousX - previousK
+
+		for x > previousX && y > previousY {
+			x--
+			y--
+			matches = append(matches, [2]int{x, y})
+		}
+
+		x = previousX
+		y = previousY
+	}
+
+	slices.Reverse(matches)
+	return matches
+}
+
+// Compares two readers, and provides an aligned view of each one. Until both
+// readers are done reading and have been compared, the views show the readers'
+// lines as they are.
+type fileDiff struct {
+	readers [2]*reader.ReaderImpl
+
+	// One aligned view per reader, with filler lines where the other side
+	// has lines this side doesn't
+	sides [2]*diffSide
+
+	// Gets a value when a comparison finishes
+	compared chan bool
+
+	// Protects the fields below
+	lock sync.Mutex
+
+	// nil until both readers are done reading and have been compared
+	result *diffResult
+
+	// True while comparing in the background
+	comparing bool
+
+	// Bumped whenever a new result is published, see ContentGeneration()
+	generation uint64
+}
+
+// The outcome of comparing the two readers
+type diffResult struct {
+	rows []diffRow
+
+	// For each side and line index, the row that line is on
+	rowOfLine [2][]int
+
+	// For each side and row, the index of the first line on or after that
+	// row. Fillers are numbered using this.
+	nextLineIndex [2][]int
+
+	// Rows where groups of changed lines start
+	changeStarts []int
+
+	// The readers are compared again if any of these change
+	lineCounts  [2]int
+	generations [2]uint64
+}
+
+func newFileDiff(left *reader.ReaderImpl, right *reader.ReaderImpl) *fileDiff {
+	diff := &fileDiff{
+		readers:  [2]*reader.ReaderImpl{left, right},
+		compared: make(chan bool, 1),
+	}
+	diff.sides = [2]*diffSide{{diff: diff, side: 0}, {diff: diff, side: 1}}
+	return diff
+}
+
+// Which side this reader is on, or -1 if it is not being compared
+func (d *fileDiff) sideOf(r *reader.ReaderImpl) int {
+	if d == nil {
+		return -1
+	}
+	return slices.Index(d.readers[:], r)
+}
+
+// Start comparing the readers in the background if they have changed since
+// the last comparison. Until the new comparison is done, the previous result
+// stays in place.
+//
+// Please hold the lock when calling this method.
+func (d *fileDiff) update() {
+	for _, r := range d.readers {
+		if !r.ReadingDone.Load() {
+			// Wait for everything to be read before comparing. Changes in
+			// partial files would just be confusing.
+			d.result = nil
+			return
+		}
+	}
+
+	// Generations first, in case the readers change after this
+	generations := [2]uint64{d.readers[0].ContentGeneration(), d.readers[1].ContentGeneration()}
+	lineCounts := [2]int{d.readers[0].GetLineCount(), d.readers[1].GetLineCount()}
+	if d.result != nil && generations == d.result.generations && lineCounts == d.result.lineCounts {
+		return
+	}
+
+	if d.comparing {
+		// When this comparison is done, the next update() will start
+		// another one if needed
+		return
+	}
+	d.comparing = true
+
+	go func() {
+		defer func() {
+			PanicHandler("fileDiff.update()/goroutine", recover(), debug.Stack())
+		}()
+
+		result := compareReaders(d.readers, lineCounts)
+		result.generations = generations
+
+		d.lock.Lock()
+		d.result = result
+		d.comparing = false
+		d.generation++
+		d.lock.Unlock()
+
+		select {
+		case d.compared <- true:
+		default:
+		}
+	}()
+}
+
+func compareReaders(readers [2]*reader.ReaderImpl, lineCounts [2]int) *diffResult {
+	t0 := time.Now()
+	var plainLines [2][]string
+	for side, r := range readers {
+		plainLines[side] = make([]string, 0, lineCounts[side])
+		if lineCounts[side] == 0 {
+			continue
+		}
+		for _, line := range r.GetLines(linemetadata.Index{}, lineCounts[side]).Lines {
+			plainLines[side] = append(plainLines[side], line.Plain())
+		}
+	}
+
+	result := &diffResult{
+		rows:       diffLines(plainLines[0], plainLines[1]),
+		lineCounts: lineCounts,
+	}
+	for side := range result.rowOfLine {
+		result.rowOfLine[side] = make([]int, len(plainLines[side]))
+		result.nextLineIndex[side] = make([]int, len(result.rows))
+	}
+	for rowIndex, row := range result.rows {
+		if row.left >= 0 {
+			result.rowOfLine[0][row.left] = rowIndex
+		}
+		if row.right >= 0 {
+			result.rowOfLine[1][row.right] = rowIndex
+		}
+
+		startsChange := row.kind != diffLineSame && (rowIndex == 0 || result.rows[rowIndex-1].kind == diffLineSame)
+		if startsChange {
+			result.changeStarts = append(result.changeStarts, rowIndex)
+		}
+	}
+
+	// Backwards, so that we always know the next line
+	nextLineIndex := [2]int{len(plainLines[0]), len(plainLines[1])}
+	for rowIndex := len(result.rows) - 1; rowIndex >= 0; rowIndex-- {
+		row := result.rows[rowIndex]
+		if row.left >= 0 {
+			nextLineIndex[0] = row.left
+		}
+		if row.right >= 0 {
+			nextLineIndex[1] = row.right
+		}
+		result.nextLineIndex[0][rowIndex] = nextLineIndex[0]
+		result.nextLineIndex[1][rowIndex] = nextLineIndex[1]
+	}
+
+	log.Debugf("Compared %d and %d lines in %s, %d changes",
+		lineCounts[0], lineCounts[1], time.Since(t0), len(result.changeStarts))
+
+	return result
+}
+
+// The row showing a line, or the line index itself if the files haven't been
+// compared yet
+func (d *fileDiff) rowOfLineIndex(side int, lineIndex int) int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.update()
+
+	if d.result == nil {
+		return lineIndex
+	}
+	rowOfLine := d.result.rowOfLine[side]
+	if len(rowOfLine) == 0 {
+		return 0
+	}
+	return rowOfLine[max(0, min(lineIndex, len(rowOfLine)-1))]
+}
+
+// Rows where groups of changed lines start. nil until the files have been
+// compared.
+func (d *fileDiff) getChangeStarts() []int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.update()
+
+	if d.result == nil {
+		return nil
+	}
+	return d.result.changeStarts
+}
+
+// How the line differs from the other side
+func (d *fileDiff) lineKind(side int, line reader.NumberedLine) diffLineKind {
+	if line.Line == diffFillerLine {
+		// Fillers are on the other side of added or removed lines
+		if side == 0 {
+			return diffLineAdded
+		}
+		return diffLineRemoved
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.update()
+
+	lineIndex := line.Number.AsZeroBased()
+	if d.result == nil || lineIndex >= len(d.result.rowOfLine[side]) {
+		return diffLineSame
+	}
+	return d.result.rows[d.result.rowOfLine[side][lineIndex]].kind
+}
+
+// Like "3 changes", or an empty string if we haven't compared yet
+func (d *fileDiff) changesText() string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.update()
+
+	if d.result == nil {
+		return ""
+	}
+
+	switch len(d.result.changeStarts) {
+	case 0:
+		return "no changes"
+	case 1:
+		return "1 change"
+	default:
+		return fmt.Sprintf("%d changes", len(d.result.changeStarts))
+	}
+}
+
+// One side of a fileDiff, with filler lines added to align it with the other
+// side
+type diffSide struct {
+	diff *fileDiff
+	side int
+}
+
+func (s *diffSide) backingReader() *reader.ReaderImpl {
+	return s.diff.readers[s.side]
+}
+
+// Please hold the diff lock, and check that there is a result, when calling
+// this method.
+func (s *diffSide) lineAt(row int) reader.NumberedLine {
+	result := s.diff.result
+	lineIndex := result.rows[row].left
+	if s.side == 1 {
+		lineIndex = result.rows[row].right
+	}
+
+	if lineIndex >= 0 {
+		line := s.backingReader().GetLine(linemetadata.IndexFromZeroBased(lineIndex))
+		if line != nil {
+			return reader.NumberedLine{
+				Index:  linemetadata.IndexFromZeroBased(row),
+				Number: line.Number,
+				Line:   line.Line,
+			}
+		}
+	}
+
+	// Fillers get the number of the next line, so that line numbers keep
+	// increasing
+	return reader.NumberedLine{
+		Index:  linemetadata.IndexFromZeroBased(row),
+		Number: linemetadata.NumberFromZeroBased(result.nextLineIndex[s.side][row]),
+		Line:   diffFillerLine,
+	}
+}
+
+func (s *diffSide) GetLineCount() int {
+	s.diff.lock.Lock()
+	defer s.diff.lock.Unlock()
+	s.diff.update()
+
+	if s.diff.result == nil {
+		return s.backingReader().GetLineCount()
+	}
+	return len(s.diff.result.rows)
+}
+
+func (s *diffSide) ShouldShowLineCount() bool {
+	return s.backingReader().ShouldShowLineCount()
+}
+
+func (s *diffSide) GetLine(index linemetadata.Index) *reader.NumberedLine {
+	s.diff.lock.Lock()
+	defer s.diff.lock.Unlock()
+	s.diff.update()
+
+	if s.diff.result == nil {
+		return s.backingReader().GetLine(index)
+	}
+
+	if index.Index() < 0 || index.Index() >= len(s.diff.result.rows) {
+		return nil
+	}
+	line := s.lineAt(index.Index())
+	return &line
+}
+
+func (s *diffSide) GetLines(firstLine linemetadata.Index, wantedLineCount int) reader.InputLines {
+	s.diff.lock.Lock()
+	s.diff.update()
+
+	if s.diff.result == nil {
+		s.diff.lock.Unlock()
+		return s.backingReader().GetLines(firstLine, wantedLineCount)
+	}
+
+	if len(s.diff.result.rows) == 0 || wantedLineCount == 0 {
+		s.diff.lock.Unlock()
+		return s.backingReader().GetLines(firstLine, wantedLineCount)
+	}
+
+	// Prevent reading past the end, but still return as many lines as we can
+	wantedLineCount = min(wantedLineCount, len(s.diff.result.rows))
+	first := max(0, min(firstLine.Index(), len(s.diff.result.rows)-wantedLineCount))
+	lines := make([]reader.NumberedLine, 0, wantedLineCount)
+	for row := first; row < first+wantedLineCount; row++ {
+		lines = append(lines, s.lineAt(row))
+	}
+	s.diff.lock.Unlock()
+
+	// Describe the file as if the last line on screen was the last line shown
+	lastLineIndex := 0
+	for _, line := range lines {
+		if line.Line != diffFillerLine {
+			lastLineIndex = line.Number.AsZeroBased()
+		}
+	}
+	status := s.backingReader().GetLines(linemetadata.IndexFromZeroBased(lastLineIndex), 1)
+
+	statusText := status.StatusText
+	if changesText := s.diff.changesText(); changesText != "" {
+		statusText += "  " + changesText
+	}
+
+	return reader.InputLines{
+		Lines:        lines,
+		FilenameText: status.FilenameText,
+		StatusText:   statusText,
+	}
+}
+
+func (s *diffSide) GetLinesPreallocated(firstLine linemetadata.Index, resultLines *[]reader.NumberedLine) (string, string) {
+	lines := s.GetLines(firstLine, cap(*resultLines))
+
+	// Copy rather than replace, searchLineCache relies on the capacity
+	// staying the same
+	*resultLines = append((*resultLines)[:0], lines.Lines...)
+	return lines.FilenameText, lines.StatusText
+}
+
+// The filtering reader rebuilds its cache when this changes. That should
+// happen both when the files are compared again, and when any of them is
+// reloaded before that.
+func (s *diffSide) ContentGeneration() uint64 {
+	s.diff.lock.Lock()
+	defer s.diff.lock.Unlock()
+	s.diff.update()
+
+	// All of these only ever increase, so the sum changes if any of them do
+	return s.diff.generation + s.diff.readers[0].ContentGeneration() + s.diff.readers[1].ContentGeneration()
+}
@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"gotest.tools/v3/assert"
+)
+
+// Render the rows like "a|a", "b|", "|c", "d|D"
+func describeDiffRows(left []string, right []string, rows []diffRow) []string {
+	var described []string
+	for _, row := range rows {
+		leftText := ""
+		if row.left >= 0 {
+			leftText = left[row.left]
+		}
+		rightText := ""
+		if row.right >= 0 {
+			rightText = right[row.right]
+		}
+		described = append(described, leftText+"|"+rightText)
+	}
+	return described
+}
+
+func testDiffLines(t *testing.T, left string, right string, expected ...string) {
+	t.Helper()
+
+	leftLines := strings.Fields(left)
+	rightLines := strings.Fields(right)
+	rows := diffLines(leftLines, rightLines)
+	assert.DeepEqual(t, describeDiffRows(leftLines, rightLines, rows), expected)
+}
+
+func TestDiffLines(t *testing.T) {
+	testDiffLines(t, "", "")
+	testDiffLines(t, "a b", "a b", "a|a", "b|b")
+	testDiffLines(t, "a b c", "a c", "a|a", "b|", "c|c")
+	testDiffLines(t, "a c", "a b c", "a|a", "|b", "c|c")
+	testDiffLines(t, "a b c", "a B c", "a|a", "b|B", "c|c")
+	testDiffLines(t, "a b c", "a B X c", "a|a", "b|B", "|X", "c|c")
+	testDiffLines(t, "", "a b", "|a", "|b")
+	testDiffLines(t, "a b", "", "a|", "b|")
+
+	// Common lines in the middle should be found, not just at the ends
+	testDiffLines(t, "x a y b z", "a Y b", "x|", "a|a", "y|Y", "b|b", "z|")
+}
+
+func TestDiffLinesKinds(t *testing.T) {
+	rows := diffLines([]string{"a", "b", "c"}, []string{"a", "B", "c", "d"})
+	assert.Equal(t, len(rows), 4)
+	assert.Equal(t, rows[0], diffRow{left: 0, right: 0, kind: diffLineSame})
+	assert.Equal(t, rows[1], diffRow{left: 1, right: 1, kind: diffLineChanged})
+	assert.Equal(t, rows[2], diffRow{left: 2, right: 2, kind: diffLineSame})
+	assert.Equal(t, rows[3], diffRow{left: -1, right: 3, kind: diffLineAdded})
+}
+
+// Start comparing if needed, and wait for the comparison to finish. The
+// readers must be done reading.
+func (d *fileDiff) waitUntilCompared() {
+	for {
+		d.lock.Lock()
+		d.update()
+		done := d.result != nil && !d.comparing
+		d.lock.Unlock()
+
+		if done {
+			return
+		}
+		<-d.compared
+	}
+}
+
+func TestDiffSide(t *testing.T) {
+	left := reader.NewFromTextForTesting("left", "a\nb\nc\nd")
+	right := reader.NewFromTextForTesting("right", "a\nc\nD\ne")
+	assert.NilError(t, left.Wait())
+	assert.NilError(t, right.Wait())
+
+	diff := newFileDiff(left, right)
+	assert.Equal(t, diff.sides[0].GetLineCount(), 4, "Should show the file as is until compared")
+	diff.waitUntilCompared()
+	assert.Equal(t, diff.sides[0].GetLineCount(), 5)
+	assert.Equal(t, diff.sides[1].GetLineCount(), 5)
+
+	// Row 1 is "b" on the left, removed, with a filler on the right
+	leftLine := diff.sides[0].GetLine(linemetadata.IndexFromZeroBased(1))
+	assert.Equal(t, leftLine.Plain(), "b")
+	assert.Equal(t, diff.lineKind(0, *leftLine), diffLineRemoved)
+
+	rightLine := diff.sides[1].GetLine(linemetadata.IndexFromZeroBased(1))
+	assert.Equal(t, rightLine.Line, diffFillerLine)
+	assert.Equal(t, rightLine.Number, linemetadata.NumberFromOneBased(2), "Fillers should get the next line number")
+	assert.Equal(t, diff.lineKind(1, *rightLine), diffLineRemoved)
+
+	// Line numbers are the files' own
+	lines := diff.sides[1].GetLines(linemetadata.IndexFromZeroBased(3), 2)
+	assert.Equal(t, len(lines.Lines), 2)
+	assert.Equal(t, lines.Lines[0].Plain(), "D")
+	assert.Equal(t, lines.Lines[0].Index, linemetadata.IndexFromZeroBased(3))
+	assert.Equal(t, lines.Lines[0].Number, linemetadata.NumberFromOneBased(3))
+	assert.Equal(t, diff.lineKind(1, lines.Lines[0]), diffLineChanged)
+	assert.Equal(t, diff.lineKind(1, lines.Lines[1]), diffLineAdded)
+	assert.Equal(t, lines.FilenameText, "right")
+	assert.Equal(t, lines.StatusText, ": 4 lines  100%  2 changes")
+
+	assert.DeepEqual(t, diff.getChangeStarts(), []int{1, 3})
+	assert.Equal(t, diff.rowOfLineIndex(1, 2), 3)
+}
@@ -5,7 +5,9 @@ import (
 	"math"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -110,6 +112,113 @@ func pickAnEditor() (string, string, error) {
 	return "", "", fmt.Errorf("No editor found, tried: $VISUAL, $EDITOR, %s", strings.Join(candidates, ", "))
 }
 
+// How to make different editors open a file at a given line. Keys are editor
+// binary names without any path or ".exe" suffix.
+//
+// In the templates, "%d" is replaced with the line number and "%s" with the
+// file name. If there is no "%s", the file name goes last.
+var editorLineArgTemplates = map[string]string{
+	"vi":            "+%d",
+	"vim":           "+%d",
+	"nvim":          "+%d",
+	"emacs":         "+%d",
+	"emacsclient":   "+%d",
+	"nano":          "+%d",
+	"micro":         "+%d",
+	"hx":            "%s:%d", // Helix
+	"helix":         "%s:%d",
+	"code":          "--goto %s:%d", // VS Code
+	"code-insiders": "--goto %s:%d",
+	"codium":        "--goto %s:%d",
+	"subl":          "%s:%d", // Sublime Text
+}
+
+// Users with other editors can set this to a template like the ones in
+// editorLineArgTemplates. An empty value means use the built-in table.
+const editorLineArgEnvVar = "MOOR_EDITOR_LINE_ARG"
+
+// Build the command line for opening fileToEdit in the editor. If lineNumber
+// is nil, or we don't know how to tell this editor about line numbers, the
+// file will be opened without any line number.
+func editorCommandWithArgs(editor string, fileToEdit string, lineNumber *linemetadata.Number) []string {
+	commandWithArgs := strings.Fields(editor)
+
+	template := ""
+	if lineNumber != nil {
+		template = strings.TrimSpace(os.Getenv(editorLineArgEnvVar))
+		if template == "" {
+			editorName := filepath.Base(commandWithArgs[0])
+			editorName = strings.TrimSuffix(strings.ToLower(editorName), ".exe")
+			template = editorLineArgTemplates[editorName]
+		}
+	}
+
+	if template == "" {
+		return append(commandWithArgs, fileToEdit)
+	}
+
+	lineNumberString := strconv.Itoa(lineNumber.AsOneBased())
+	hasFileName := false
+	for _, word := range strings.Fields(template) {
+		if strings.Contains(word, "%s") {
+			hasFileName = true
+		}
+
+		// Replace %s last so that any "%d" in the file name is left alone
+		word = strings.ReplaceAll(word, "%d", lineNumberString)
+		word = strings.ReplaceAll(word, "%s", fileToEdit)
+		commandWithArgs = append(commandWithArgs, word)
+	}
+
+	if !hasFileName {
+		commandWithArgs = append(commandWithArgs, fileToEdit)
+	}
+
+	return commandWithArgs
+}
+
+// Which line should the editor start at? The cursor line if there is one. If
+// there is a search hit on screen, that's the one, otherwise the first visible
+// line. Returns nil if we don't know.
+func (p *Pager) editorLineNumber() *linemetadata.Number {
+	if p.isShowingHelp {
+		// Help text line numbers don't point into the file
+		return nil
+	}
+
+	if line := p.cursorLine(); line != nil {
+		return &line.Number
+	}
+
+	inputLines := p.renderLines().inputLines
+	if len(inputLines) == 0 {
+		return nil
+	}
+
+	// Separators between groups of filtered lines aren't part of the file
+	fileLines := make([]reader.NumberedLine, 0, len(inputLines))
+	for _, line := range inputLines {
+		if p.filteredLineKind(line.Index) != filteredLineSeparator {
+			fileLines = append(fileLines, line)
+		}
+	}
+	if len(fileLines) == 0 {
+		return nil
+	}
+
+	if p.search.Active() {
+		for _, line := range fileLines {
+			if p.search.Matches(line.Plain()) {
+				return &line.Number
+			}
+		}
+	}
+
+	// The top line may be partially scrolled off screen if it's wrapped, but
+	// it's still the line the user is looking at.
+	return &fileLines[0].Number
+}
+
 func handleEditingRequest(p *Pager) {
 	if os.Getenv("LESSSECURE") == "1" {
 		p.mode = &PagerModeInfo{
@@ -155,6 +264,8 @@ func handleEditingRequest(p *Pager) {
 		}
 	}
 
+	lineNumber := p.editorLineNumber()
+
 	var fileToEdit string
 	if canOpenFile {
 		fileToEdit = *p.readers[p.currentReader].FileName
@@ -184,8 +295,7 @@ func handleEditingRequest(p *Pager) {
 	err = p.screen.PauseAndCall(func() error {
 		// NOTE: If you do any changes here, make sure they work with both "nano"
 		// and "code -w" (VSCode).
-		commandWithArgs := strings.Fields(editor)
-		commandWithArgs = append(commandWithArgs, fileToEdit)
+		commandWithArgs := editorCommandWithArgs(editor, fileToEdit, lineNumber)
 
 		log.Info("'v' pressed, launching editor: ", commandWithArgs)
 		command := exec.Command(commandWithArgs[0], commandWithArgs[1:]...)
@@ -220,4 +330,10 @@ func handleEditingRequest(p *Pager) {
 
 		return
 	}
+
+	if canOpenFile {
+		// Show the user what they just did. Temp files are gone by now, so
+		// only do this for real files.
+		p.ReloadCurrentReader()
+	}
 }
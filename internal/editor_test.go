@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestEditorCommandWithArgs_NoLineNumber(t *testing.T) {
+	t.Setenv(editorLineArgEnvVar, "")
+
+	assert.DeepEqual(t,
+		editorCommandWithArgs("vim", "file.txt", nil),
+		[]string{"vim", "file.txt"})
+}
+
+func TestEditorCommandWithArgs_KnownEditors(t *testing.T) {
+	t.Setenv(editorLineArgEnvVar, "")
+	lineNumber := linemetadata.NumberFromOneBased(42)
+
+	assert.DeepEqual(t,
+		editorCommandWithArgs("/usr/bin/nvim", "file.txt", &lineNumber),
+		[]string{"/usr/bin/nvim", "+42", "file.txt"})
+	assert.DeepEqual(t,
+		editorCommandWithArgs("code -w", "file.txt", &lineNumber),
+		[]string{"code", "-w", "--goto", "file.txt:42"})
+	assert.DeepEqual(t,
+		editorCommandWithArgs("hx", "file.txt", &lineNumber),
+		[]string{"hx", "file.txt:42"})
+	assert.DeepEqual(t,
+		editorCommandWithArgs("subl", "file.txt", &lineNumber),
+		[]string{"subl", "file.txt:42"})
+}
+
+func TestEditorCommandWithArgs_UnknownEditor(t *testing.T) {
+	t.Setenv(editorLineArgEnvVar, "")
+	lineNumber := linemetadata.NumberFromOneBased(42)
+
+	assert.DeepEqual(t,
+		editorCommandWithArgs("ed", "file.txt", &lineNumber),
+		[]string{"ed", "file.txt"})
+}
+
+func TestEditorCommandWithArgs_FromEnvironment(t *testing.T) {
+	lineNumber := linemetadata.NumberFromOneBased(42)
+
+	t.Setenv(editorLineArgEnvVar, "-l %d")
+	assert.DeepEqual(t,
+		editorCommandWithArgs("ed", "file.txt", &lineNumber),
+		[]string{"ed", "-l", "42", "file.txt"})
+
+	t.Setenv(editorLineArgEnvVar, "%s#L%d")
+	assert.DeepEqual(t,
+		editorCommandWithArgs("vim", "%d.txt", &lineNumber),
+		[]string{"vim", "%d.txt#L42"})
+}
+
+func TestEditorLineNumber(t *testing.T) {
+	r := reader.NewFromTextForTesting("", "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl")
+	pager := NewPager(r)
+	pager.screen = twin.NewFakeScreen(20, 5)
+	pager.scrollPosition = NewScrollPositionFromIndex(linemetadata.IndexFromZeroBased(3), "TestEditorLineNumber")
+
+	// No search, first visible line
+	assert.Equal(t, pager.editorLineNumber().AsOneBased(), 4)
+
+	// Search hit on screen
+	pager.search.For("f")
+	assert.Equal(t, pager.editorLineNumber().AsOneBased(), 6)
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/internal/textstyles"
+)
+
+// FileSettings are view settings for one file, overriding the pager wide
+// settings while that file is shown. Nil fields mean "use the pager wide
+// setting".
+//
+// These come from file type sections in the config file, like wrapping
+// Markdown files but nothing else.
+type FileSettings struct {
+	WrapLongLines   *bool
+	ShowLineNumbers *bool
+	TabSize         *int
+}
+
+// The pager wide settings, to go back to when switching away from a file with
+// its own settings
+type baseFileSettings struct {
+	wrapLongLines   bool
+	showLineNumbers bool
+	tabSize         int
+}
+
+// SetFileSettings makes the settings apply whenever the reader is shown
+func (p *Pager) SetFileSettings(r *reader.ReaderImpl, settings FileSettings) {
+	if settings == (FileSettings{}) {
+		// No overrides. Storing these would make us go back to the pager
+		// wide settings when leaving the file, forgetting any changes the
+		// user made while viewing it.
+		delete(p.fileSettings, r)
+		return
+	}
+
+	if p.fileSettings == nil {
+		p.fileSettings = make(map[*reader.ReaderImpl]FileSettings)
+	}
+	p.fileSettings[r] = settings
+}
+
+// Call when switching from one reader to another. The from reader can be nil
+// when starting up.
+func (p *Pager) applyFileSettings(from *reader.ReaderImpl, to *reader.ReaderImpl) {
+	if _, found := p.fileSettings[from]; found {
+		// Leaving a file with its own settings, go back to the pager wide ones
+		p.WrapLongLines = p.baseFileSettings.wrapLongLines
+		p.ShowLineNumbers = p.baseFileSettings.showLineNumbers
+		p.TabSize = p.baseFileSettings.tabSize
+	} else {
+		// Whatever the user changed while viewing this file is the new pager
+		// wide setting
+		p.baseFileSettings = baseFileSettings{
+			wrapLongLines:   p.WrapLongLines,
+			showLineNumbers: p.ShowLineNumbers,
+			tabSize:         p.TabSize,
+		}
+	}
+
+	settings := p.fileSettings[to]
+	if settings.WrapLongLines != nil {
+		p.WrapLongLines = *settings.WrapLongLines
+	}
+	if settings.ShowLineNumbers != nil {
+		p.ShowLineNumbers = *settings.ShowLineNumbers
+	}
+	if settings.TabSize != nil {
+		p.TabSize = *settings.TabSize
+	}
+
+	p.showLineNumbers = p.ShowLineNumbers
+	if p.TabSize > 0 {
+		// "0" = unset, stay at the default. If the tab size is negative, just
+		// ignoring it seems like the right move.
+		textstyles.TabSize = p.TabSize
+	}
+}
@@ -1,7 +1,12 @@
 package internal
 
 import (
+	"errors"
+	"slices"
+
 	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
 )
 
 func (p *Pager) previousFile() {
@@ -51,11 +56,107 @@ func (p *Pager) firstFile() {
 	}
 }
 
+func (p *Pager) goToFile(newIndex int) {
+	p.readerLock.Lock()
+	defer p.readerLock.Unlock()
+
+	p.switchToFile(newIndex)
+	log.Tracef("Switched to file index %d", p.currentReader)
+
+	select {
+	case p.readerSwitched <- struct{}{}:
+	default:
+	}
+}
+
+// Add a reader to the end of the list and switch to it
+func (p *Pager) addReader(r *reader.ReaderImpl) {
+	p.readerLock.Lock()
+	p.readers = append(p.readers, r)
+	p.switchToFile(len(p.readers) - 1)
+	log.Tracef("Added reader, now at index %d", p.currentReader)
+	p.readerLock.Unlock()
+
+	select {
+	case p.readerSwitched <- struct{}{}:
+	default:
+	}
+}
+
+// Remove the current file from the list, and switch to the file after it. If
+// there is no file after it, switch to the one before it.
+func (p *Pager) closeCurrentFile() error {
+	p.readerLock.Lock()
+	if len(p.readers) == 1 {
+		p.readerLock.Unlock()
+		return errors.New("Not closing the only file, quit instead")
+	}
+
+	closing := p.readers[p.currentReader]
+	if p.diff.sideOf(closing) >= 0 {
+		p.readerLock.Unlock()
+		return errors.New("Not closing a file being compared")
+	}
+
+	newIndex := p.currentReader + 1
+	if newIndex >= len(p.readers) {
+		newIndex = p.currentReader - 1
+	}
+	p.switchToFile(newIndex)
+
+	// Make a new slice rather than changing the old one, other goroutines may
+	// be looking at it
+	closingIndex := slices.Index(p.readers, closing)
+	p.readers = slices.Concat(p.readers[:closingIndex], p.readers[closingIndex+1:])
+	if p.currentReader > closingIndex {
+		p.currentReader--
+	}
+	delete(p.fileSettings, closing)
+	delete(p.jumpLists, closing)
+	log.Tracef("Closed file index %d, now at index %d", closingIndex, p.currentReader)
+	p.readerLock.Unlock()
+
+	p.forgetPaneReader(closing)
+
+	closing.Close()
+
+	select {
+	case p.readerSwitched <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Switch to the given file and scroll to the given line as soon as the line is
+// available
+func (p *Pager) switchToFileAtLine(newIndex int, lineIndex linemetadata.Index) {
+	p.readerLock.Lock()
+	p.switchToFile(newIndex)
+	log.Tracef("Switched to file index %d, going for line %s", p.currentReader, lineIndex.Format())
+	p.readerLock.Unlock()
+
+	select {
+	case p.readerSwitched <- struct{}{}:
+	default:
+	}
+
+	p.setTargetLine(&lineIndex)
+}
+
 func (p *Pager) switchToFile(newIndex int) {
 	if newIndex == p.currentReader {
 		return
 	}
 
+	p.applyFileSettings(p.readers[p.currentReader], p.readers[newIndex])
+	if !p.isShowingHelp {
+		// For coming back here after switching back to this file
+		p.jumpListOf(p.readers[p.currentReader]).record(p.scrollPosition)
+	}
 	p.currentReader = newIndex
 	p.scrollPosition = newScrollPosition("Pager file switch")
+
+	// Filters are for the file they were made for
+	p.clearFilters()
 }
@@ -1,7 +1,9 @@
 package internal
 
 import (
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/walles/moor/v2/internal/linemetadata"
@@ -43,3 +45,200 @@ func TestIssue426SwitchingToSmallerFileClearsStaleScrollPosition(t *testing.T) {
 	assert.Equal(t, renderedToString(rendered.lines[0].cells), "small")
 	assert.Equal(t, pager.lineIndex().Index(), 0)
 }
+
+func TestSearchAcrossFiles(t *testing.T) {
+	first := reader.NewFromTextForTesting("first", "a\nb\nc")
+	second := reader.NewFromTextForTesting("second", "x\ny\nb\nz")
+	assert.NilError(t, first.Wait())
+	assert.NilError(t, second.Wait())
+
+	pager := NewPager(first, second)
+	pager.screen = twin.NewFakeScreen(20, 10)
+	pager.mode = PagerModeViewing{pager: pager}
+	pager.search.For("b")
+
+	// Without the option, we should stay in the first file
+	pager.scrollToNextSearchHit()
+	assert.Equal(t, "NotFound", modeName(pager))
+	assert.Equal(t, pager.currentReader, 0)
+
+	pager.mode = PagerModeViewing{pager: pager}
+	pager.SearchAcrossFiles = true
+	pager.scrollToNextSearchHit()
+	assert.Equal(t, "Viewing", modeName(pager))
+	assert.Equal(t, pager.currentReader, 1)
+	assert.Equal(t, pager.TargetLine.Index(), 2)
+
+	// And back again
+	pager.filteringReader.SetBackingReader(second)
+	pager.scrollPosition = newScrollPosition("TestSearchAcrossFiles")
+	pager.scrollToPreviousSearchHit()
+	assert.Equal(t, pager.currentReader, 0)
+	assert.Equal(t, pager.TargetLine.Index(), 1)
+}
+
+func TestSearchAllFiles(t *testing.T) {
+	first := reader.NewFromTextForTesting("first", "a\nb\nc")
+	second := reader.NewFromTextForTesting("second", "x")
+	third := reader.NewFromTextForTesting("third", "b\nb\nb")
+	assert.NilError(t, first.Wait())
+	assert.NilError(t, second.Wait())
+	assert.NilError(t, third.Wait())
+
+	pager := NewPager(first, second, third)
+	pager.screen = twin.NewFakeScreen(80, 10)
+	pager.search.For("b")
+
+	pager.searchAllFiles()
+	mode := pager.mode.(*PagerModeSearchAllFiles)
+	<-mode.searched
+	assert.Equal(t, len(mode.files), 2)
+	assert.Equal(t, mode.files[0].name, "first")
+	assert.Equal(t, len(mode.files[0].hits), 1)
+	assert.Equal(t, mode.files[1].name, "third")
+	assert.Equal(t, len(mode.files[1].hits), 3)
+
+	// Jump to the third file
+	mode.onRune('3')
+	assert.Equal(t, pager.currentReader, 2)
+	assert.Equal(t, pager.TargetLine.Index(), 0)
+}
+
+func TestSearchAllFilesWithManyFiles(t *testing.T) {
+	readers := []*reader.ReaderImpl{}
+	for i := range 12 {
+		text := "nothing"
+		if i == 0 || i == 11 {
+			text = "hit"
+		}
+		r := reader.NewFromTextForTesting(strconv.Itoa(i+1), text)
+		assert.NilError(t, r.Wait())
+		readers = append(readers, r)
+	}
+
+	pager := NewPager(readers...)
+	pager.screen = twin.NewFakeScreen(80, 10)
+	pager.search.For("hit")
+
+	// "1" could be the start of "12", so wait for more
+	pager.searchAllFiles()
+	<-pager.mode.(*PagerModeSearchAllFiles).searched
+	pager.mode.onRune('1')
+	pager.mode.onRune('2')
+	assert.Equal(t, pager.currentReader, 11)
+
+	// ENTER says we're done typing
+	pager.searchAllFiles()
+	<-pager.mode.(*PagerModeSearchAllFiles).searched
+	pager.mode.onRune('1')
+	assert.Equal(t, pager.currentReader, 11)
+	pager.mode.onKey(twin.KeyEnter)
+	assert.Equal(t, pager.currentReader, 0)
+}
+
+func TestSearchAllFilesPartiallyRead(t *testing.T) {
+	done := reader.NewFromTextForTesting("done", "hit")
+	partial := reader.NewFromTextForTesting("partial", "hit")
+	empty := reader.NewFromTextForTesting("empty", "")
+	assert.NilError(t, done.Wait())
+	assert.NilError(t, partial.Wait())
+	assert.NilError(t, empty.Wait())
+
+	// Pretend there's more to come
+	partial.ReadingDone.Store(false)
+	partial.PauseStatus = &atomic.Bool{}
+
+	screen := twin.NewFakeScreen(80, 10)
+	pager := NewPager(done, partial, empty)
+	pager.screen = screen
+	pager.search.For("hit")
+
+	pager.searchAllFiles()
+	mode := pager.mode.(*PagerModeSearchAllFiles)
+	<-mode.searched
+	mode.drawFooter("", "", "")
+	footer := rowToString(screen.GetRow(9))
+	assert.Assert(t, strings.HasPrefix(footer, "Hits: 1 done (1)  2 partial (1, reading...)  Type"), footer)
+
+	// Nothing found
+	pager.search.For("miss")
+	pager.searchAllFiles()
+	mode = pager.mode.(*PagerModeSearchAllFiles)
+	<-mode.searched
+	mode.drawFooter("", "", "")
+	footer = rowToString(screen.GetRow(9))
+	assert.Assert(t, strings.HasPrefix(footer, "Not found in any file: miss  ESC to exit"), footer)
+}
+
+func TestFileSettingsFollowTheCurrentFile(t *testing.T) {
+	plain := reader.NewFromTextForTesting("plain.txt", "plain")
+	markdown := reader.NewFromTextForTesting("README.md", "markdown")
+
+	pager := NewPager(plain, markdown)
+	pager.screen = twin.NewFakeScreen(80, 10)
+	wrap := true
+	tabSize := 4
+	pager.SetFileSettings(markdown, FileSettings{WrapLongLines: &wrap, TabSize: &tabSize})
+	pager.applyFileSettings(nil, plain)
+
+	// The user changes a setting in the first file...
+	pager.ShowLineNumbers = false
+	assert.Equal(t, pager.WrapLongLines, false)
+
+	pager.nextFile()
+	assert.Equal(t, pager.WrapLongLines, true)
+	assert.Equal(t, pager.TabSize, 4)
+	assert.Equal(t, pager.ShowLineNumbers, false, "Settings without overrides should stay")
+
+	// ... and the settings without overrides stay when going back
+	pager.previousFile()
+	assert.Equal(t, pager.WrapLongLines, false)
+	assert.Equal(t, pager.TabSize, 8)
+	assert.Equal(t, pager.ShowLineNumbers, false)
+}
+
+// Files without any overrides shouldn't make us forget what the user changed
+func TestEmptyFileSettingsAreNotOverrides(t *testing.T) {
+	first := reader.NewFromTextForTesting("first", "1")
+	second := reader.NewFromTextForTesting("second", "2")
+
+	pager := NewPager(first, second)
+	pager.screen = twin.NewFakeScreen(80, 10)
+	pager.SetFileSettings(first, FileSettings{})
+	pager.SetFileSettings(second, FileSettings{})
+	pager.applyFileSettings(nil, first)
+
+	pager.WrapLongLines = true
+	pager.nextFile()
+	assert.Equal(t, pager.WrapLongLines, true)
+
+	pager.WrapLongLines = false
+	pager.previousFile()
+	assert.Equal(t, pager.WrapLongLines, false)
+}
+
+func TestCloseCurrentFile(t *testing.T) {
+	first := reader.NewFromTextForTesting("first", "1")
+	second := reader.NewFromTextForTesting("second", "2")
+	third := reader.NewFromTextForTesting("third", "3")
+
+	pager := NewPager(first, second, third)
+	pager.screen = twin.NewFakeScreen(20, 10)
+	readersBefore := pager.readers
+
+	pager.nextFile()
+	assert.NilError(t, pager.closeCurrentFile())
+	assert.Equal(t, len(pager.readers), 2)
+	assert.Equal(t, pager.readers[0], first)
+	assert.Equal(t, pager.readers[1], third)
+	assert.Equal(t, pager.currentReader, 1, "Should have switched to the file after the closed one")
+	assert.Equal(t, readersBefore[1], second, "Old readers slice should be left alone")
+
+	assert.NilError(t, pager.closeCurrentFile())
+	assert.Equal(t, len(pager.readers), 1)
+	assert.Equal(t, pager.readers[0], first)
+	assert.Equal(t, pager.currentReader, 0, "Closing the last file should switch to the one before it")
+
+	assert.Error(t, pager.closeCurrentFile(), "Not closing the only file, quit instead")
+	assert.Equal(t, len(pager.readers), 1)
+}
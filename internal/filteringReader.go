@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"math"
 	"runtime"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,6 +17,34 @@ import (
 
 // Filters lines based on the search query from the pager.
 
+// How many lines to show around each filter match, like grep -B and -A
+type FilterContext struct {
+	Before int
+	After  int
+}
+
+// Returns something like "-C3" or "-B1 -A2", or an empty string if there is no
+// context
+func (c FilterContext) String() string {
+	if c.Before == 0 && c.After == 0 {
+		return ""
+	}
+	if c.Before == c.After {
+		return fmt.Sprintf("-C%d", c.Before)
+	}
+	return fmt.Sprintf("-B%d -A%d", c.Before, c.After)
+}
+
+type filteredLineKind int
+
+const (
+	filteredLineMatch filteredLineKind = iota
+	filteredLineContext
+	filteredLineSeparator // Between non-adjacent groups of lines
+)
+
+var filterContextSeparator = reader.NewLine("--")
+
 type FilteringReader struct {
 	BackingReader reader.Reader
 
@@ -22,6 +52,13 @@ type FilteringReader struct {
 	// including if it is set to nil.
 	Filter *search.Search
 
+	// Filters applied before Filter, each one narrowing the view further. Can
+	// be nil. Lines must match all of these to be shown.
+	Stack *[]search.Search
+
+	// Lines to show around each match. Can be nil, meaning no context.
+	Context *FilterContext
+
 	// Protects filteredLinesCache, unfilteredLineCountWhenCaching, and
 	// filterPatternWhenCaching.
 	lock sync.Mutex
@@ -29,51 +66,172 @@ type FilteringReader struct {
 	// nil means no filtering has happened yet
 	filteredLinesCache *[]reader.NumberedLine
 
+	// What each line in the filteredLinesCache is
+	lineKindsCache []filteredLineKind
+
+	// Whether each of the first unfilteredLineCountWhenCaching lines of the
+	// backing reader matches the filter. Kept so that we only need to filter
+	// new lines when the backing reader grows.
+	matchesCache []bool
+
 	// This is what the reader's line count was when we filtered. If the
-	// reader's current line count doesn't match, then our cache needs to be
-	// rebuilt.
+	// reader's current line count is higher, then we filter the new lines and
+	// add them to the cache. If it's lower, the cache needs to be rebuilt.
 	unfilteredLineCountWhenCaching int
 
-	// This is the pattern that was used when we cached the lines. If it
-	// doesn't match the current pattern, then our cache needs to be rebuilt.
-	filterWhenCaching search.Search
+	// These are the patterns that were used when we cached the lines. If they
+	// don't match the current patterns, then our cache needs to be rebuilt.
+	filtersWhenCaching []search.Search
+
+	// Same as filtersWhenCaching, but for the context
+	contextWhenCaching FilterContext
+
+	// If the backing reader's content generation changes, its lines have been
+	// replaced rather than appended to, and our cache needs to be rebuilt.
+	contentGenerationWhenCaching uint64
+}
+
+// Implemented by readers that can tell us whether their lines have been
+// replaced, say by a reload. For other readers we have to assume the worst
+// whenever the line count changes.
+type contentGenerationReader interface {
+	ContentGeneration() uint64
 }
 
 // Please hold the lock when calling this method.
 //
-// This method requires f.Filter.Active() to be true on entry.
+// This method requires at least one active filter on entry.
 func (f *FilteringReader) rebuildCache() {
-	filter := *f.Filter
-	if !filter.Active() {
+	filters := f.activeFilters()
+	if len(filters) == 0 {
 		panic("Rebuilding cache requires an active filter")
 	}
 
-	t0 := time.Now()
+	context := FilterContext{}
+	if f.Context != nil {
+		context = *f.Context
+	}
+
+	// Mark cache base conditions
+	f.filtersWhenCaching = filters
+	f.contextWhenCaching = context
+	if generationReader, ok := f.BackingReader.(contentGenerationReader); ok {
+		// Note that this must be done before getting the line count. If the
+		// lines are replaced after this, the next call will notice and rebuild
+		// the cache again.
+		f.contentGenerationWhenCaching = generationReader.ContentGeneration()
+	}
 
 	cache := make([]reader.NumberedLine, 0)
+	f.filteredLinesCache = &cache
+	f.lineKindsCache = nil
+	f.matchesCache = nil
+	f.unfilteredLineCountWhenCaching = 0
 
-	// Mark cache base conditions
-	f.unfilteredLineCountWhenCaching = f.BackingReader.GetLineCount()
-	f.filterWhenCaching = filter
+	f.extendCache()
+}
 
-	// Repopulate the cache
-	resultIndex := 0
+// Filter any lines added to the backing reader since we last cached, and add
+// the results to the end of the cache.
+//
+// Please hold the lock when calling this method.
+func (f *FilteringReader) extendCache() {
+	t0 := time.Now()
+
+	filters := f.filtersWhenCaching
+	context := f.contextWhenCaching
 
+	oldLineCount := f.unfilteredLineCountWhenCaching
 	numLines := f.BackingReader.GetLineCount()
-	if numLines == 0 {
-		f.filteredLinesCache = &cache
-		log.Debugf("Filtered out 0/0 lines in %s", time.Since(t0))
+	f.unfilteredLineCountWhenCaching = numLines
+	if numLines <= oldLineCount {
 		return
 	}
 
-	// This completely avoids mutex locks and race conditions during the concurrent phase, while also preserving order.
-	matches := make([]bool, numLines)
+	// The last old line may have been a partial line that has been appended
+	// to since, so filter that one again
+	firstToFilter := max(0, oldLineCount-1)
+	f.matchesCache = f.matchesCache[:firstToFilter]
+
+	numWorkers := f.filterNewLines(filters, firstToFilter, numLines)
+	t1 := time.Now()
+
+	// New matches can turn the last few old lines into context lines, so
+	// re-assemble those. Lines before that are unaffected by the new lines.
+	firstChanged := max(0, firstToFilter-context.Before)
+	cache := *f.filteredLinesCache
+	kinds := f.lineKindsCache
+	for len(cache) > 0 && cache[len(cache)-1].Number.AsZeroBased() >= firstChanged {
+		cache = cache[:len(cache)-1]
+		kinds = kinds[:len(kinds)-1]
+	}
+
+	lastIncluded := -1
+	if len(cache) > 0 {
+		lastIncluded = cache[len(cache)-1].Number.AsZeroBased()
+	}
+
+	// Assemble sequentially to ensure resultIndex increments correctly
+	lineCache := searchLineCache{}
+	for i := firstChanged; i < numLines; i++ {
+		if !f.matchesCache[i] && !f.isContextLine(i, context) {
+			continue
+		}
+
+		line := lineCache.GetLine(f.BackingReader, linemetadata.IndexFromZeroBased(i), SearchDirectionForward)
+		if line == nil {
+			// The backing reader shrunk under our feet, the next call will
+			// notice the changed line count and rebuild
+			break
+		}
+
+		if lastIncluded >= 0 && i > lastIncluded+1 && (context.Before > 0 || context.After > 0) {
+			// Separate non-adjacent groups, just like grep does. Separators
+			// get the number of the next line, so that line numbers keep
+			// increasing. They aren't part of the file though, so anything
+			// looking for a file line should skip them, see skipSeparator().
+			cache = append(cache, reader.NumberedLine{
+				Line:   filterContextSeparator,
+				Index:  linemetadata.IndexFromZeroBased(len(cache)),
+				Number: line.Number,
+			})
+			kinds = append(kinds, filteredLineSeparator)
+		}
+
+		cache = append(cache, reader.NumberedLine{
+			Line:   line.Line,
+			Index:  linemetadata.IndexFromZeroBased(len(cache)),
+			Number: line.Number,
+		})
+		if f.matchesCache[i] {
+			kinds = append(kinds, filteredLineMatch)
+		} else {
+			kinds = append(kinds, filteredLineContext)
+		}
+		lastIncluded = i
+	}
+
+	f.lineKindsCache = kinds
+	f.filteredLinesCache = &cache
+
+	log.Debugf("Filtered out %d/%d lines in %s (filtered %d new lines using %d workers in %s)",
+		numLines-len(cache), numLines, time.Since(t0), numLines-firstToFilter, numWorkers, t1.Sub(t0))
+}
+
+// Check lines firstLine up to (not including) lastLine against the filters,
+// and add the results to f.matchesCache. Returns the number of workers used.
+//
+// Please hold the lock when calling this method.
+func (f *FilteringReader) filterNewLines(filters []search.Search, firstLine int, lastLine int) int {
+	// This completely avoids mutex locks and race conditions during the
+	// concurrent phase, while also preserving order.
+	matches := make([]bool, lastLine-firstLine)
 
 	var wg sync.WaitGroup
-	numWorkers := min(runtime.GOMAXPROCS(0), numLines)
+	numWorkers := min(runtime.GOMAXPROCS(0), len(matches))
 
 	// chunk size for each goroutine
-	chunkSize := (numLines + numWorkers - 1) / numWorkers
+	chunkSize := (len(matches) + numWorkers - 1) / numWorkers
 
 	// Concurrent Filtering Phase
 	for i := range numWorkers {
@@ -84,36 +242,65 @@ func (f *FilteringReader) rebuildCache() {
 			lineCache := searchLineCache{}
 
 			start := workerIndex * chunkSize
-			end := min(start+chunkSize, numLines)
+			end := min(start+chunkSize, len(matches))
 
 			for j := start; j < end; j++ {
-				line := lineCache.GetLine(f.BackingReader, linemetadata.IndexFromZeroBased(j), SearchDirectionForward)
-				matches[j] = filter.Matches(line.Line.Plain(line.Index))
+				line := lineCache.GetLine(f.BackingReader, linemetadata.IndexFromZeroBased(firstLine+j), SearchDirectionForward)
+				if line == nil {
+					// The backing reader shrunk, we'll rebuild on the next call
+					continue
+				}
+				matches[j] = matchesAll(filters, line.Line.Plain(line.Index))
 			}
 		}(i)
 	}
 
 	wg.Wait()
-	t1 := time.Now()
 
-	// Assemble sequentially to ensure resultIndex increments correctly
-	lineCache := searchLineCache{}
-	for i := range numLines {
-		if matches[i] {
-			line := lineCache.GetLine(f.BackingReader, linemetadata.IndexFromZeroBased(i), SearchDirectionForward)
-			cache = append(cache, reader.NumberedLine{
-				Line:   line.Line,
-				Index:  linemetadata.IndexFromZeroBased(resultIndex),
-				Number: line.Number,
-			})
-			resultIndex++
+	f.matchesCache = append(f.matchesCache, matches...)
+
+	return numWorkers
+}
+
+func matchesAll(filters []search.Search, line string) bool {
+	for _, filter := range filters {
+		if !filter.Matches(line) {
+			return false
 		}
 	}
+	return true
+}
 
-	f.filteredLinesCache = &cache
+// The stacked filters followed by the current one, skipping inactive ones.
+//
+// Please hold the lock when calling this method.
+func (f *FilteringReader) activeFilters() []search.Search {
+	filters := []search.Search{}
+	if f.Stack != nil {
+		for _, filter := range *f.Stack {
+			if filter.Active() {
+				filters = append(filters, filter)
+			}
+		}
+	}
+	if f.Filter != nil && f.Filter.Active() {
+		filters = append(filters, *f.Filter)
+	}
+	return filters
+}
+
+// Is there a match close enough before or after this line for it to be shown
+// as context?
+func (f *FilteringReader) isContextLine(lineIndex int, context FilterContext) bool {
+	firstMatch := max(0, lineIndex-context.After)
+	lastMatch := min(len(f.matchesCache)-1, lineIndex+context.Before)
+	for i := firstMatch; i <= lastMatch; i++ {
+		if f.matchesCache[i] {
+			return true
+		}
+	}
 
-	log.Debugf("Filtered out %d/%d lines in %s (used %d workers in %s)",
-		numLines-len(cache), numLines, time.Since(t0), numWorkers, t1.Sub(t0))
+	return false
 }
 
 func (f *FilteringReader) getAllLines() []reader.NumberedLine {
@@ -125,24 +312,39 @@ func (f *FilteringReader) getAllLines() []reader.NumberedLine {
 		return *f.filteredLinesCache
 	}
 
-	if f.unfilteredLineCountWhenCaching != f.BackingReader.GetLineCount() {
+	if !slices.EqualFunc(f.activeFilters(), f.filtersWhenCaching, search.Search.Equals) {
 		f.rebuildCache()
 		return *f.filteredLinesCache
 	}
 
-	var currentFilterPattern search.Search
-	if (*f).Filter.Active() {
-		currentFilterPattern = *f.Filter
+	currentContext := FilterContext{}
+	if f.Context != nil {
+		currentContext = *f.Context
 	}
-	var cacheFilterPattern search.Search
-	if f.filterWhenCaching.Active() {
-		cacheFilterPattern = f.filterWhenCaching
+	if currentContext != f.contextWhenCaching {
+		f.rebuildCache()
+		return *f.filteredLinesCache
 	}
-	if !currentFilterPattern.Equals(cacheFilterPattern) {
+
+	generationReader, canTellReloads := f.BackingReader.(contentGenerationReader)
+	if canTellReloads && generationReader.ContentGeneration() != f.contentGenerationWhenCaching {
+		// Reloaded, start over
 		f.rebuildCache()
 		return *f.filteredLinesCache
 	}
 
+	lineCount := f.BackingReader.GetLineCount()
+	if lineCount == f.unfilteredLineCountWhenCaching {
+		return *f.filteredLinesCache
+	}
+
+	if canTellReloads && lineCount > f.unfilteredLineCountWhenCaching {
+		// Lines were only appended, just filter the new ones
+		f.extendCache()
+		return *f.filteredLinesCache
+	}
+
+	f.rebuildCache()
 	return *f.filteredLinesCache
 }
 
@@ -150,7 +352,7 @@ func (f *FilteringReader) shouldPassThrough() bool {
 	f.lock.Lock()
 	defer f.lock.Unlock()
 
-	if f.Filter == nil || f.Filter.Inactive() {
+	if len(f.activeFilters()) == 0 {
 		// Cache is not needed
 		f.filteredLinesCache = nil
 
@@ -161,6 +363,24 @@ func (f *FilteringReader) shouldPassThrough() bool {
 	return false
 }
 
+// Is the line at this index a match, a context line or a separator?
+func (f *FilteringReader) lineKind(index linemetadata.Index) filteredLineKind {
+	if f.shouldPassThrough() {
+		return filteredLineMatch
+	}
+
+	// Bring the kinds up to date with the filters and the backing reader
+	f.getAllLines()
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if index.Index() >= len(f.lineKindsCache) {
+		return filteredLineMatch
+	}
+	return f.lineKindsCache[index.Index()]
+}
+
 func (f *FilteringReader) GetLineCount() int {
 	if f.shouldPassThrough() {
 		return f.BackingReader.GetLineCount()
@@ -224,16 +444,34 @@ func (f *FilteringReader) GetLinesPreallocated(firstLine linemetadata.Index, res
 	}
 
 	lines := f.GetLines(firstLine, cap(*resultLines))
-	*resultLines = lines.Lines
+
+	// Copy rather than replace, searchLineCache relies on the capacity
+	// staying the same
+	*resultLines = append((*resultLines)[:0], lines.Lines...)
 	return lines.FilenameText, lines.StatusText
 }
 
 // In the general case, this will return a text like this:
-// "Filtered: 1234/5678 lines  22%"
+// "Filtered [ERROR && !retrying]: 1234/5678 lines  22%"
 func (f *FilteringReader) createStatus(lastLine *linemetadata.Index) string {
+	prefix := "Filtered: "
+	if len(f.filtersWhenCaching) > 0 {
+		descriptions := []string{}
+		for _, filter := range f.filtersWhenCaching {
+			descriptions = append(descriptions, filter.String())
+		}
+
+		// Example: "ERROR > !retrying -C2"
+		description := strings.Join(descriptions, " > ")
+		if f.contextWhenCaching != (FilterContext{}) {
+			description += " " + f.contextWhenCaching.String()
+		}
+		prefix = "Filtered [" + description + "]: "
+	}
+
 	baseCount := f.BackingReader.GetLineCount()
 	if baseCount == 0 {
-		return "Filtered: No input lines"
+		return prefix + "No input lines"
 	}
 
 	baseCountString := "/" + linemetadata.IndexFromLength(baseCount).Format()
@@ -243,21 +481,39 @@ func (f *FilteringReader) createStatus(lastLine *linemetadata.Index) string {
 
 	if lastLine == nil {
 		// 100% because we're showing all 0 lines
-		return "Filtered: 0" + baseCountString + " lines  100%"
+		return prefix + "0" + baseCountString + " lines  100%"
 	}
 
-	acceptedCount := f.GetLineCount()
+	// Context lines and separators are shown, but only matches are counted
+	f.lock.Lock()
+	kinds := f.lineKindsCache
+	f.lock.Unlock()
+	acceptedCount := countMatches(kinds)
 	acceptedCountString := linemetadata.IndexFromLength(acceptedCount).Format()
 
-	percent := int(math.Floor(100 * float64(lastLine.Index()+1) / float64(acceptedCount)))
+	percent := 100
+	if acceptedCount > 0 {
+		shownCount := countMatches(kinds[:min(lastLine.Index()+1, len(kinds))])
+		percent = int(math.Floor(100 * float64(shownCount) / float64(acceptedCount)))
+	}
 
 	lineString := "line"
 	if (len(baseCountString) > 0 && baseCount != 1) || (len(baseCountString) == 0 && acceptedCount != 1) {
 		lineString += "s"
 	}
 
-	return fmt.Sprintf("Filtered: %s%s %s  %d%%",
-		acceptedCountString, baseCountString, lineString, percent)
+	return fmt.Sprintf("%s%s%s %s  %d%%",
+		prefix, acceptedCountString, baseCountString, lineString, percent)
+}
+
+func countMatches(kinds []filteredLineKind) int {
+	count := 0
+	for _, kind := range kinds {
+		if kind == filteredLineMatch {
+			count++
+		}
+	}
+	return count
 }
 
 // SetBackingReader switches the underlying reader while holding the lock and
@@ -274,6 +530,8 @@ func (f *FilteringReader) SetBackingReader(r reader.Reader) {
 
 	// Invalidate caches so they will be rebuilt lazily on next access.
 	f.filteredLinesCache = nil
+	f.lineKindsCache = nil
+	f.matchesCache = nil
 	f.unfilteredLineCountWhenCaching = -1
-	f.filterWhenCaching = search.Search{}
+	f.filtersWhenCaching = nil
 }
@@ -1,10 +1,12 @@
 package internal
 
 import (
+	"io"
 	"os"
 	"strings"
 	"testing"
 
+	"github.com/walles/moor/v2/internal/linemetadata"
 	"github.com/walles/moor/v2/internal/reader"
 	"github.com/walles/moor/v2/internal/search"
 	"github.com/walles/moor/v2/twin"
@@ -46,6 +48,157 @@ func TestZeroLines(t *testing.T) {
 	assert.Equal(t, len(rendered.lines), 0)
 }
 
+func TestFilterContext(t *testing.T) {
+	backing := reader.NewFromTextForTesting("test", "a\nb\nX1\nc\nd\ne\nf\nX2\ng")
+	assert.NilError(t, backing.Wait())
+
+	filter := search.For("X")
+	context := FilterContext{Before: 1, After: 1}
+	filtering := FilteringReader{
+		BackingReader: backing,
+		Filter:        &filter,
+		Context:       &context,
+	}
+
+	lines := filtering.getAllLines()
+	texts := []string{}
+	numbers := []int{}
+	for _, line := range lines {
+		texts = append(texts, line.Line.Plain(line.Index))
+		numbers = append(numbers, line.Number.AsOneBased())
+	}
+	assert.DeepEqual(t, texts, []string{"b", "X1", "c", "--", "f", "X2", "g"})
+	assert.DeepEqual(t, numbers, []int{2, 3, 4, 7, 7, 8, 9})
+
+	assert.Equal(t, filtering.lineKind(lines[0].Index), filteredLineContext)
+	assert.Equal(t, filtering.lineKind(lines[1].Index), filteredLineMatch)
+	assert.Equal(t, filtering.lineKind(lines[3].Index), filteredLineSeparator)
+
+	// Overlapping context should merge the groups
+	context = FilterContext{Before: 2, After: 2}
+	assert.Equal(t, filtering.GetLineCount(), 9)
+	assert.Equal(t, filtering.lineKind(lines[3].Index), filteredLineContext)
+
+	// No context, no separators
+	context = FilterContext{}
+	assert.Equal(t, filtering.GetLineCount(), 2)
+
+	// Line kinds should follow context changes without any other calls first
+	context = FilterContext{Before: 1, After: 1}
+	assert.Equal(t, filtering.lineKind(lines[3].Index), filteredLineSeparator)
+
+	// Only matches should be counted in the status
+	status := filtering.GetLines(linemetadata.Index{}, 3).StatusText
+	assert.Assert(t, strings.HasSuffix(status, ": 2/9 lines  50%"), status)
+}
+
+// Shows only the first lineCount lines of the backing reader, pretending that
+// the rest haven't been read yet
+type growingReader struct {
+	*reader.ReaderImpl
+	lineCount  int
+	generation uint64
+
+	// Lowest line index requested since this was last reset to -1
+	lowestRequested int
+}
+
+func (r *growingReader) GetLineCount() int {
+	return r.lineCount
+}
+
+func (r *growingReader) ContentGeneration() uint64 {
+	return r.generation
+}
+
+func (r *growingReader) GetLinesPreallocated(firstLine linemetadata.Index, resultLines *[]reader.NumberedLine) (string, string) {
+	if r.lowestRequested < 0 || firstLine.Index() < r.lowestRequested {
+		r.lowestRequested = firstLine.Index()
+	}
+	return r.ReaderImpl.GetLinesPreallocated(firstLine, resultLines)
+}
+
+func filteredTexts(lines []reader.NumberedLine) []string {
+	texts := []string{}
+	for _, line := range lines {
+		texts = append(texts, line.Line.Plain(line.Index))
+	}
+	return texts
+}
+
+func TestFilterCacheOnlyFiltersNewLines(t *testing.T) {
+	impl := reader.NewFromTextForTesting("test", "a\nX1\nb\nc\nX2\nd\ne\nf\nX3")
+	assert.NilError(t, impl.Wait())
+	backing := &growingReader{ReaderImpl: impl, lineCount: 4, lowestRequested: -1}
+
+	filter := search.For("X")
+	context := FilterContext{Before: 1, After: 1}
+	filtering := FilteringReader{
+		BackingReader: backing,
+		Filter:        &filter,
+		Context:       &context,
+	}
+	assert.DeepEqual(t, filteredTexts(filtering.getAllLines()), []string{"a", "X1", "b"})
+
+	backing.lineCount = 9
+	backing.lowestRequested = -1
+	lines := filtering.getAllLines()
+	assert.DeepEqual(t, filteredTexts(lines), []string{"a", "X1", "b", "c", "X2", "d", "--", "f", "X3"})
+	assert.Equal(t, backing.lowestRequested, 2, "Only lines that can be affected by the new lines should be looked at")
+	for i, line := range lines {
+		assert.Equal(t, line.Index.Index(), i)
+	}
+	assert.Equal(t, filtering.lineKind(lines[3].Index), filteredLineContext)
+	assert.Equal(t, filtering.lineKind(lines[6].Index), filteredLineSeparator)
+
+	// Reloading should make us start over
+	backing.generation++
+	backing.lowestRequested = -1
+	assert.Equal(t, filtering.GetLineCount(), 9)
+	assert.Equal(t, backing.lowestRequested, 0)
+}
+
+// Partial last lines are appended to when the rest of the line arrives
+func TestFilterCacheRefiltersPartialLastLine(t *testing.T) {
+	input, output := io.Pipe()
+	defer func() { _ = output.Close() }()
+	go func() { _, _ = output.Write([]byte("a\nX")) }()
+
+	backing, err := reader.NewFromStream("test", input, nil, reader.ReaderOptions{})
+	assert.NilError(t, err)
+	for backing.GetLineCount() < 2 {
+		<-backing.MoreLinesAdded
+	}
+
+	filter := search.For("Xy")
+	filtering := FilteringReader{
+		BackingReader: backing,
+		Filter:        &filter,
+	}
+	assert.Equal(t, filtering.GetLineCount(), 0)
+
+	go func() { _, _ = output.Write([]byte("y\nc")) }()
+	for backing.GetLineCount() < 3 {
+		<-backing.MoreLinesAdded
+	}
+
+	assert.DeepEqual(t, filteredTexts(filtering.getAllLines()), []string{"Xy"})
+}
+
+func TestParseFilterContext(t *testing.T) {
+	context, err := parseFilterContext("3")
+	assert.NilError(t, err)
+	assert.Equal(t, context, FilterContext{Before: 3, After: 3})
+
+	context, err = parseFilterContext("1, 2")
+	assert.NilError(t, err)
+	assert.Equal(t, context, FilterContext{Before: 1, After: 2})
+	assert.Equal(t, context.String(), "-B1 -A2")
+
+	_, err = parseFilterContext("-1")
+	assert.ErrorContains(t, err, "not a line count")
+}
+
 // Micro benchmark for rebuildCache (the main computation in filteringReader.go)
 func BenchmarkFilterHugeFile(b *testing.B) {
 	// The file packets_repeat.log is 18 kB and 90 lines long (with ANSI colors).
@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/walles/moor/v2/internal/config"
+	"github.com/walles/moor/v2/internal/reader"
+)
+
+const helpIntro = `
+Welcome to Moor, the nice pager!
+`
+
+// Prose following the generated key lists in some sections
+var helpSectionNotes = map[string]string{
+	sectionFiltering: `
+Filter expressions can combine patterns using '!' (not), '&&' (and), '||' (or)
+and parentheses. Example: ERROR && !(retrying || healthcheck). Put patterns
+containing any of those characters within double quotes.
+
+While filtering, PageUp and PageDown work as usual. Press up / down arrows to
+access the filter history. Press CTRL-x to step through some context sizes,
+like grep -C does. Context lines are dimmed, and '--' separates groups of lines.
+
+Press 'ESC' or RETURN to exit filtering mode.
+`,
+
+	sectionPanes: `
+Each pane shows its own file, at its own position and with its own search.
+Filters only apply to the focused pane, and are cleared when moving focus.
+`,
+
+	sectionDiff: `
+Start moor with --diff old.txt new.txt to show two files side by side. Added
+lines are highlighted in green, removed lines in red and changed lines in blue.
+Both sides scroll together.
+`,
+
+	sectionSearching: `
+Type RETURN to stop searching, or ESC to skip back to where the search started.
+Press up / down arrows while searching to access search history.
+
+Search is case sensitive if it contains any UPPER CASE CHARACTERS. Search is
+interpreted as a regexp if it is a valid one. While searching or filtering,
+CTRL-r forces regexp, CTRL-n forces literal text, CTRL-s forces case
+sensitivity and CTRL-w matches whole words only.
+
+Start with --search-across-files to make searching for the next / previous hit
+continue into the next / previous file.
+`,
+}
+
+const commandsNote = `
+Type a line number, like ':50', or a percentage, like ':50%', to go there.
+Press TAB to complete what you are typing, and up / down arrows to access the
+command history.
+`
+
+const helpOutro = `
+Key bindings
+------------
+Keys can be rebound in the [keys] section of the config file, like this:
+  quit = ["q", "ESC"]
+  search-next = "CTRL-n"
+
+The config file is at %s.
+
+Available actions are:
+%s
+
+Reporting bugs
+--------------
+File issues at https://github.com/walles/moor/issues, or post
+questions to johan.walles@gmail.com.
+
+Installing Moor as your default pager
+-------------------------------------
+Put the following line in your ~/.bashrc, ~/.bash_profile or ~/.zshrc:
+  export PAGER=moor
+
+Source Code
+-----------
+Available at https://github.com/walles/moor/.
+`
+
+// Generate the help text from the key bindings in the keymap
+func createHelpReader(keymap Keymap) *reader.ReaderImpl {
+	return reader.NewFromTextForTesting("Help", helpText(keymap))
+}
+
+func helpText(keymap Keymap) string {
+	var text strings.Builder
+	text.WriteString(helpIntro)
+
+	section := ""
+	for _, info := range allActions {
+		if info.section != section {
+			text.WriteString(helpSectionNotes[section])
+
+			section = info.section
+			text.WriteString("\n" + section + "\n")
+			text.WriteString(strings.Repeat("-", len(section)) + "\n")
+		}
+
+		keys := keymap.describeKeys(info.name)
+		if keys == "" {
+			// Unbound, nothing to press
+			continue
+		}
+		text.WriteString(wrapHelpLine("* " + keys + ": " + info.description))
+	}
+	text.WriteString(helpSectionNotes[section])
+
+	text.WriteString("\nCommands\n--------\n")
+	for _, command := range colonCommands {
+		text.WriteString(wrapHelpLine("* " + describeColonCommand(command) + ": " + command.description))
+	}
+	text.WriteString(commandsNote)
+
+	actionNames := []string{}
+	for _, info := range allActions {
+		actionNames = append(actionNames, string(info.name))
+	}
+	text.WriteString(fmt.Sprintf(helpOutro, config.DefaultPath(), strings.TrimSuffix(wrapText("  ", strings.Join(actionNames, ", ")), "\n")))
+
+	return text.String()
+}
+
+// Like "':edit <file>' / ':e <file>'"
+func describeColonCommand(command colonCommand) string {
+	descriptions := []string{}
+	for _, name := range command.names {
+		description := ":" + name
+		if command.args != "" {
+			description += " " + command.args
+		}
+		descriptions = append(descriptions, "'"+description+"'")
+	}
+	return strings.Join(descriptions, " / ")
+}
+
+// Wrap a bullet point, indenting continuation lines to line up with the text
+func wrapHelpLine(line string) string {
+	return strings.TrimPrefix(wrapText("  ", line), "  ")
+}
+
+// Word wrap to 80 columns, with each line starting with the indent
+func wrapText(indent string, text string) string {
+	const width = 80
+
+	var result strings.Builder
+	current := indent
+	for _, word := range strings.Fields(text) {
+		if current != indent && len(current)+1+len(word) > width {
+			result.WriteString(current + "\n")
+			current = indent
+		}
+		if current != indent {
+			current += " "
+		}
+		current += word
+	}
+	result.WriteString(current + "\n")
+
+	return result.String()
+}
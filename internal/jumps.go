@@ -0,0 +1,146 @@
+// Go back and forth between the positions we jumped away from, like in vim.
+// Each file has its own jump list.
+
+package internal
+
+import (
+	"github.com/walles/moor/v2/internal/reader"
+)
+
+// Older jumps are forgotten
+const maxJumps = 100
+
+type jumpList struct {
+	positions []scrollPosition
+
+	// Index into positions. Equal to len(positions) unless we're moving back
+	// and forth through the list.
+	current int
+}
+
+// Remember a position we're jumping away from. Anything we had moved back past
+// is forgotten.
+func (j *jumpList) record(position scrollPosition) {
+	j.positions = j.positions[:j.current]
+	if len(j.positions) > 0 && sameJumpPosition(j.positions[len(j.positions)-1], position) {
+		// Already there
+		j.current = len(j.positions)
+		return
+	}
+
+	j.positions = append(j.positions, position)
+	if len(j.positions) > maxJumps {
+		j.positions = j.positions[len(j.positions)-maxJumps:]
+	}
+	j.current = len(j.positions)
+}
+
+// Returns false if there is nowhere to go back to. When going back from the
+// end of the list, here is remembered so that we can come back to it.
+func (j *jumpList) back(here scrollPosition) (scrollPosition, bool) {
+	if j.current == len(j.positions) && j.current > 0 {
+		if sameJumpPosition(j.positions[j.current-1], here) {
+			// We're at the last recorded position, go to the one before it
+			j.current--
+		} else {
+			j.positions = append(j.positions, here)
+		}
+	}
+
+	if j.current == 0 {
+		return scrollPosition{}, false
+	}
+
+	j.current--
+	return j.positions[j.current], true
+}
+
+// Returns false if there is nowhere to go forward to
+func (j *jumpList) forward() (scrollPosition, bool) {
+	if j.current >= len(j.positions)-1 {
+		return scrollPosition{}, false
+	}
+
+	j.current++
+	return j.positions[j.current], true
+}
+
+// Unlike ScrollPositionsEqual(), this doesn't canonicalize the positions. That
+// would require rendering, which we can't do while switching files.
+func sameJumpPosition(a scrollPosition, b scrollPosition) bool {
+	aIndex := a.internalDontTouch.lineIndex
+	bIndex := b.internalDontTouch.lineIndex
+	if (aIndex == nil) != (bIndex == nil) {
+		return false
+	}
+	if aIndex != nil && *aIndex != *bIndex {
+		return false
+	}
+
+	return a.internalDontTouch.delta == b.internalDontTouch.delta
+}
+
+func (p *Pager) jumpListOf(r *reader.ReaderImpl) *jumpList {
+	if p.jumpLists == nil {
+		p.jumpLists = map[*reader.ReaderImpl]*jumpList{}
+	}
+
+	list, found := p.jumpLists[r]
+	if !found {
+		list = &jumpList{}
+		p.jumpLists[r] = list
+	}
+	return list
+}
+
+func (p *Pager) currentJumpList() *jumpList {
+	p.readerLock.Lock()
+	r := p.readers[p.currentReader]
+	p.readerLock.Unlock()
+
+	return p.jumpListOf(r)
+}
+
+// Call before a big jump, like going to a line or to a search hit
+func (p *Pager) recordJump() {
+	p.recordJumpFrom(p.scrollPosition)
+}
+
+// Like recordJump(), but for when we have already moved away from the position
+func (p *Pager) recordJumpFrom(position scrollPosition) {
+	if p.isShowingHelp {
+		return
+	}
+
+	p.currentJumpList().record(position)
+}
+
+func (p *Pager) jumpBack() {
+	if p.isShowingHelp {
+		return
+	}
+
+	destination, ok := p.currentJumpList().back(p.scrollPosition)
+	if !ok {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Nowhere to jump back to"}
+		return
+	}
+
+	p.scrollPosition = destination
+	p.setTargetLine(nil)
+}
+
+func (p *Pager) jumpForward() {
+	if p.isShowingHelp {
+		return
+	}
+
+	destination, ok := p.currentJumpList().forward()
+	if !ok {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Nowhere to jump forward to"}
+		return
+	}
+
+	p.scrollPosition = destination
+	p.setTargetLine(nil)
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func jumpTestPosition(index int) scrollPosition {
+	return NewScrollPositionFromIndex(linemetadata.IndexFromZeroBased(index), "jumpTestPosition")
+}
+
+// -1 if there was nowhere to jump to
+func jumpTestIndex(position scrollPosition, ok bool) int {
+	if !ok {
+		return -1
+	}
+	return position.internalDontTouch.lineIndex.Index()
+}
+
+func TestJumpList(t *testing.T) {
+	jumps := jumpList{}
+	_, ok := jumps.back(jumpTestPosition(0))
+	assert.Assert(t, !ok, "Nothing recorded yet")
+
+	jumps.record(jumpTestPosition(1))
+	jumps.record(jumpTestPosition(2))
+	jumps.record(jumpTestPosition(2)) // Duplicates are ignored
+
+	// Going back from 3 should remember 3 for going forward again
+	assert.Equal(t, jumpTestIndex(jumps.back(jumpTestPosition(3))), 2)
+	assert.Equal(t, jumpTestIndex(jumps.back(jumpTestPosition(2))), 1)
+	_, ok = jumps.back(jumpTestPosition(1))
+	assert.Assert(t, !ok)
+
+	assert.Equal(t, jumpTestIndex(jumps.forward()), 2)
+	assert.Equal(t, jumpTestIndex(jumps.forward()), 3)
+	_, ok = jumps.forward()
+	assert.Assert(t, !ok)
+
+	// Jumping from the middle of the list forgets what's after it
+	jumps.back(jumpTestPosition(3))
+	jumps.record(jumpTestPosition(2))
+	_, ok = jumps.forward()
+	assert.Assert(t, !ok)
+	assert.Equal(t, len(jumps.positions), 2)
+}
+
+func TestJumpBackAfterGoto(t *testing.T) {
+	pager := newCommandTestPager(t)
+
+	assert.NilError(t, pager.runColonCommand("50"))
+	assert.Equal(t, pager.lineIndex().Index(), 49)
+
+	pager.mode.onRune('\x0f') // CTRL-o
+	assert.Equal(t, pager.lineIndex().Index(), 0)
+
+	pager.mode.onRune('\t') // CTRL-i
+	assert.Equal(t, pager.lineIndex().Index(), 49)
+
+	pager.mode.onRune('\t')
+	assert.Equal(t, "Info", modeName(pager), "Nowhere to go forward to")
+}
+
+func TestJumpBackAfterGG(t *testing.T) {
+	pager := newCommandTestPager(t)
+	pager.scrollPosition = jumpTestPosition(49)
+
+	pager.mode.onRune('g')
+	pager.mode.onRune('g')
+	assert.Equal(t, pager.lineIndex().Index(), 0)
+
+	pager.mode.onRune('\x0f') // CTRL-o
+	assert.Equal(t, pager.lineIndex().Index(), 49)
+}
+
+func TestJumpBackAfterSwitchingFiles(t *testing.T) {
+	first := reader.NewFromTextForTesting("first", "1\n2\n3\n4\n5\n6\n7\n8\n9")
+	second := reader.NewFromTextForTesting("second", "other")
+	assert.NilError(t, first.Wait())
+	assert.NilError(t, second.Wait())
+
+	pager := NewPager(first, second)
+	pager.screen = twin.NewFakeScreen(20, 4)
+	pager.mode = PagerModeViewing{pager: pager}
+	pager.scrollPosition = jumpTestPosition(5)
+
+	pager.nextFile()
+	pager.filteringReader.SetBackingReader(second)
+	pager.previousFile()
+	pager.filteringReader.SetBackingReader(first)
+	assert.Equal(t, pager.lineIndex().Index(), 0, "Switching files starts at the top")
+
+	pager.jumpBack()
+	assert.Equal(t, pager.lineIndex().Index(), 5)
+}
@@ -0,0 +1,231 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/walles/moor/v2/internal/config"
+	"github.com/walles/moor/v2/twin"
+)
+
+// Names of the special keys, as used in the config file and in the help text.
+// Printable characters are named by themselves, and control characters are
+// named like "CTRL-f".
+var keyCodeNames = map[twin.KeyCode]string{
+	twin.KeyEscape:    "ESC",
+	twin.KeyEnter:     "RETURN",
+	twin.KeyBackspace: "BACKSPACE",
+	twin.KeyDelete:    "DELETE",
+	twin.KeyUp:        "UP",
+	twin.KeyDown:      "DOWN",
+	twin.KeyRight:     "RIGHT",
+	twin.KeyLeft:      "LEFT",
+	twin.KeyAltUp:     "ALT-UP",
+	twin.KeyAltDown:   "ALT-DOWN",
+	twin.KeyAltRight:  "ALT-RIGHT",
+	twin.KeyAltLeft:   "ALT-LEFT",
+	twin.KeyHome:      "HOME",
+	twin.KeyEnd:       "END",
+	twin.KeyPgUp:      "PAGEUP",
+	twin.KeyPgDown:    "PAGEDOWN",
+}
+
+// Alternative spellings accepted in the config file
+var keyNameAliases = map[string]string{
+	"ESCAPE":   "ESC",
+	"ENTER":    "RETURN",
+	"PGUP":     "PAGEUP",
+	"PGDN":     "PAGEDOWN",
+	"PGDOWN":   "PAGEDOWN",
+	"DEL":      "DELETE",
+	"SPACEBAR": "SPACE",
+}
+
+func keyCodeName(key twin.KeyCode) string {
+	name, found := keyCodeNames[key]
+	if !found {
+		return fmt.Sprintf("KEY-%d", key)
+	}
+	return name
+}
+
+func runeKeyName(char rune) string {
+	if char >= 1 && char <= 26 {
+		return "CTRL-" + string('a'+char-1)
+	}
+	if char == ' ' {
+		return "SPACE"
+	}
+	return string(char)
+}
+
+// Turn a key name from the config file into the form we use internally.
+// Returns an error for unknown key names.
+func canonicalKeyName(name string) (string, error) {
+	if utf8.RuneCountInString(name) == 1 {
+		// A single character, case matters
+		return name, nil
+	}
+
+	upper := strings.ToUpper(name)
+	if alias, found := keyNameAliases[upper]; found {
+		upper = alias
+	}
+
+	if upper == "SPACE" {
+		return upper, nil
+	}
+
+	for _, keyName := range keyCodeNames {
+		if upper == keyName {
+			return upper, nil
+		}
+	}
+
+	if strings.HasPrefix(upper, "CTRL-") && len(upper) == len("CTRL-x") {
+		letter := strings.ToLower(upper[len("CTRL-"):])
+		if letter[0] >= 'a' && letter[0] <= 'z' {
+			return "CTRL-" + letter, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown key %q", name)
+}
+
+// Maps key names to the actions they trigger while viewing
+type Keymap struct {
+	// Key name to action
+	actions map[string]action
+
+	// Action to key names, in the order they should be presented to the user
+	keys map[action][]string
+}
+
+func newDefaultKeymap() Keymap {
+	keymap := Keymap{
+		actions: map[string]action{},
+		keys:    map[action][]string{},
+	}
+
+	for _, info := range allActions {
+		for _, key := range info.defaultKeys {
+			keymap.actions[key] = info.name
+			keymap.keys[info.name] = append(keymap.keys[info.name], key)
+		}
+	}
+
+	return keymap
+}
+
+func (k Keymap) actionForRune(char rune) (action, bool) {
+	a, found := k.actions[runeKeyName(char)]
+	return a, found
+}
+
+func (k Keymap) actionForKey(key twin.KeyCode) (action, bool) {
+	a, found := k.actions[keyCodeName(key)]
+	return a, found
+}
+
+// The keys bound to an action, in presentation order
+func (k Keymap) keysFor(a action) []string {
+	return k.keys[a]
+}
+
+// Like "'q' / 'ESC'", for the status bar and the help text. Empty if the action
+// isn't bound to any key.
+func (k Keymap) describeKeys(a action) string {
+	quoted := []string{}
+	for _, key := range k.keysFor(a) {
+		quoted = append(quoted, quoteKeyName(key))
+	}
+	return strings.Join(quoted, " / ")
+}
+
+func quoteKeyName(key string) string {
+	if key == "'" {
+		// Quoting this one would just be confusing
+		return "' (single quote)"
+	}
+	return "'" + key + "'"
+}
+
+// Like describeKeys(), but only the first key
+func (k Keymap) describeFirstKey(a action) string {
+	keys := k.keysFor(a)
+	if len(keys) == 0 {
+		return ""
+	}
+	return quoteKeyName(keys[0])
+}
+
+// Bind the given keys to an action, replacing its previous keys. Keys that were
+// bound to other actions are moved over to this one. An empty list of keys
+// unbinds the action.
+func (k *Keymap) bind(actionName string, keyNames []string) error {
+	if findAction(action(actionName)) == nil {
+		return fmt.Errorf("unknown action %q", actionName)
+	}
+	a := action(actionName)
+
+	canonicalKeys := []string{}
+	for _, keyName := range keyNames {
+		canonical, err := canonicalKeyName(keyName)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(canonicalKeys, canonical) {
+			canonicalKeys = append(canonicalKeys, canonical)
+		}
+	}
+
+	// Unbind the old keys of this action
+	for _, key := range k.keys[a] {
+		delete(k.actions, key)
+	}
+	delete(k.keys, a)
+
+	for _, key := range canonicalKeys {
+		if previous, found := k.actions[key]; found {
+			// Steal the key from the other action
+			k.keys[previous] = slices.DeleteFunc(k.keys[previous], func(other string) bool {
+				return other == key
+			})
+		}
+
+		k.actions[key] = a
+		k.keys[a] = append(k.keys[a], key)
+	}
+
+	return nil
+}
+
+// KeymapFromConfig returns the default keymap, modified by the [keys] section
+// of a config file. Each entry maps an action name to a key or a list of keys.
+// Entries that can't be used are skipped, and reported in the returned list of
+// problems.
+func KeymapFromConfig(section *config.Section) (Keymap, []error) {
+	keymap := newDefaultKeymap()
+	if section == nil {
+		return keymap, nil
+	}
+
+	var problems []error
+	for _, actionName := range section.Keys {
+		value := section.Values[actionName]
+		err := keymap.bind(actionName, value.Strings)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("[%s] %s: %w", section.Name, actionName, err))
+		}
+	}
+
+	return keymap, problems
+}
+
+// SetKeymap changes what the keys do, and updates the help text to match
+func (p *Pager) SetKeymap(keymap Keymap) {
+	p.keymap = keymap
+	p.helpReader = createHelpReader(p.keymap)
+}
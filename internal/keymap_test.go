@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/config"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func keymapFromConfigText(t *testing.T, text string) (Keymap, []error) {
+	t.Helper()
+	file, err := config.Parse(text)
+	assert.NilError(t, err)
+	return KeymapFromConfig(file.Section("keys"))
+}
+
+func TestDefaultKeymapIsComplete(t *testing.T) {
+	keymap := newDefaultKeymap()
+	for _, info := range allActions {
+		assert.Assert(t, len(keymap.keysFor(info.name)) > 0, "No default keys for %s", info.name)
+		for _, key := range info.defaultKeys {
+			canonical, err := canonicalKeyName(key)
+			assert.NilError(t, err)
+			assert.Equal(t, canonical, key, "Default keys should be canonical")
+		}
+	}
+
+	found, ok := keymap.actionForRune('\x06') // CTRL-f
+	assert.Assert(t, ok)
+	assert.Equal(t, found, actionPageDown)
+
+	found, ok = keymap.actionForKey(twin.KeyEscape)
+	assert.Assert(t, ok)
+	assert.Equal(t, found, actionQuit)
+}
+
+func TestKeymapFromConfig(t *testing.T) {
+	keymap, problems := keymapFromConfigText(t, `
+[keys]
+quit = "x"
+search-next = [
+  "ctrl-n",
+  "enter",
+]
+`)
+	assert.Equal(t, len(problems), 0)
+
+	found, _ := keymap.actionForRune('x')
+	assert.Equal(t, found, actionQuit)
+
+	_, ok := keymap.actionForRune('q')
+	assert.Assert(t, !ok, "Rebinding quit should unbind its old keys")
+
+	// Both keys should have been moved over from scroll-down
+	found, _ = keymap.actionForRune('\x0e')
+	assert.Equal(t, found, actionSearchNext)
+	found, _ = keymap.actionForKey(twin.KeyEnter)
+	assert.Equal(t, found, actionSearchNext)
+	assert.DeepEqual(t, keymap.keysFor(actionScrollDown), []string{"DOWN", "j", "e"})
+}
+
+func TestKeymapFromConfigErrors(t *testing.T) {
+	_, problems := keymapFromConfigText(t, "[keys]\n\nfly = \"x\"\n")
+	assert.Equal(t, len(problems), 1)
+	assert.Error(t, problems[0], `[keys] fly: unknown action "fly"`)
+
+	// The bad binding is skipped, the good one is still used
+	keymap, problems := keymapFromConfigText(t, "[keys]\nquit = \"CTRL-1\"\ntoggle-wrap = \"W\"\n")
+	assert.Equal(t, len(problems), 1)
+	assert.Error(t, problems[0], `[keys] quit: unknown key "CTRL-1"`)
+	assert.DeepEqual(t, keymap.keysFor(actionQuit), newDefaultKeymap().keysFor(actionQuit))
+	assert.DeepEqual(t, keymap.keysFor(actionToggleWrap), []string{"W"})
+}
+
+func TestRemappedKeysInHelp(t *testing.T) {
+	keymap, problems := keymapFromConfigText(t, "[keys]\ntoggle-wrap = \"W\"\nhelp = \"F\"\n")
+	assert.Equal(t, len(problems), 0)
+
+	pager := NewPager(reader.NewFromTextForTesting("test", "text"))
+	screen := twin.NewFakeScreen(80, 10)
+	pager.screen = screen
+	pager.SetKeymap(keymap)
+
+	help := helpText(pager.keymap)
+	assert.Assert(t, strings.Contains(help, "* 'W': toggle wrapping of long lines"), help)
+	assert.Assert(t, !strings.Contains(help, "* 'w':"), help)
+
+	// The footer should tell the user about the new help key
+	PagerModeViewing{pager: pager}.drawFooter("", "", "")
+	footer := rowToString(screen.GetRow(9))
+	assert.Assert(t, strings.HasSuffix(footer, "F for help"), footer)
+
+	// And pressing it should show the help
+	pager.mode.onRune('F')
+	assert.Assert(t, pager.isShowingHelp)
+	assert.Equal(t, pager.Reader(), reader.Reader(pager.helpReader))
+}
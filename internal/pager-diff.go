@@ -0,0 +1,191 @@
+package internal
+
+import (
+	"math"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+)
+
+// Show the first two files side by side, each one in its own pane
+func (p *Pager) startDiff() {
+	if len(p.readers) != 2 {
+		log.Warnf("Comparing files needs exactly two files, got %d", len(p.readers))
+		return
+	}
+
+	p.diff = newFileDiff(p.readers[0], p.readers[1])
+	for _, r := range p.readers {
+		// We need all lines for comparing
+		r.SetPauseAfterLines(math.MaxInt)
+	}
+
+	p.filteringReader.SetBackingReader(p.diff.sides[0])
+	p.split(true)
+	if !p.isSplit() {
+		// Not enough room, the reason is in p.mode
+		return
+	}
+
+	// Show the second file in the new pane
+	p.readerLock.Lock()
+	p.switchToFile(1)
+	p.readerLock.Unlock()
+	p.filteringReader.SetBackingReader(p.diff.sides[1])
+}
+
+// What the user should see of a reader. While comparing files, that's the
+// aligned view.
+func (p *Pager) viewOf(r *reader.ReaderImpl) reader.Reader {
+	side := p.diff.sideOf(r)
+	if side < 0 {
+		return r
+	}
+	return p.diff.sides[side]
+}
+
+// 0 for the left file, 1 for the right one, or -1 if the current file is not
+// being compared
+func (p *Pager) diffSideIndex() int {
+	if p.diff == nil || p.isShowingHelp {
+		return -1
+	}
+
+	p.readerLock.Lock()
+	r := p.readers[p.currentReader]
+	p.readerLock.Unlock()
+
+	return p.diff.sideOf(r)
+}
+
+// The row at the top of the screen, if the current file is being compared
+func (p *Pager) diffRow() (int, bool) {
+	side := p.diffSideIndex()
+	if side < 0 {
+		return 0, false
+	}
+
+	lineIndex := p.lineIndex()
+	if lineIndex == nil {
+		return 0, false
+	}
+	if !p.isFiltering() {
+		return lineIndex.Index(), true
+	}
+
+	// Find our way back from the filtered view using the line number
+	line := p.Reader().GetLine(*lineIndex)
+	if line == nil {
+		return 0, false
+	}
+	return p.diff.rowOfLineIndex(side, line.Number.AsZeroBased()), true
+}
+
+// Scroll the other panes showing files being compared along with this one
+func (p *Pager) syncDiffPanes() {
+	row, ok := p.diffRow()
+	if !ok {
+		return
+	}
+
+	for i := range p.panes {
+		if i == p.focusedPane || p.diff.sideOf(p.panes[i].reader) < 0 {
+			continue
+		}
+
+		p.panes[i].scrollPosition = NewScrollPositionFromIndex(linemetadata.IndexFromZeroBased(row), "syncDiffPanes")
+		p.panes[i].leftColumnZeroBased = p.leftColumnZeroBased
+	}
+}
+
+// Line numbers typed by the user are the file's own, not the aligned view's
+func (p *Pager) viewIndexOfLine(lineIndex linemetadata.Index) linemetadata.Index {
+	side := p.diffSideIndex()
+	if side < 0 || p.isFiltering() {
+		return lineIndex
+	}
+	return linemetadata.IndexFromZeroBased(p.diff.rowOfLineIndex(side, lineIndex.Index()))
+}
+
+func (p *Pager) scrollToNextChange() {
+	p.scrollToChange(true)
+}
+
+func (p *Pager) scrollToPreviousChange() {
+	p.scrollToChange(false)
+}
+
+// Put the first line of the next or previous group of changed lines at the top
+// of the screen
+func (p *Pager) scrollToChange(forward bool) {
+	if p.diffSideIndex() < 0 {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Not comparing files, try 'moor --diff old.txt new.txt'"}
+		return
+	}
+
+	if p.isFiltering() {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Jumping between changes doesn't work while filtering, press " + p.keymap.describeFirstKey(actionPopFilter) + " to remove filters"}
+		return
+	}
+
+	changeStarts := p.diff.getChangeStarts()
+	if changeStarts == nil {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Still reading, changes will be found when both files are done"}
+		return
+	}
+	if len(changeStarts) == 0 {
+		p.mode = &PagerModeInfo{Pager: p, Text: "The files are the same"}
+		return
+	}
+
+	current, _ := p.diffRow()
+	target := -1
+	if forward {
+		for _, start := range changeStarts {
+			if start > current {
+				target = start
+				break
+			}
+		}
+	} else {
+		for _, start := range changeStarts {
+			if start >= current {
+				break
+			}
+			target = start
+		}
+	}
+
+	if target < 0 {
+		text := "No more changes below"
+		if !forward {
+			text = "No more changes above"
+		}
+		p.mode = &PagerModeInfo{Pager: p, Text: text}
+		return
+	}
+
+	p.scrollPosition = NewScrollPositionFromIndex(linemetadata.IndexFromZeroBased(target), "scrollToChange")
+	p.setTargetLine(nil)
+}
+
+// How to highlight a line of a file being compared, or nil for no highlighting
+func (p *Pager) diffLineBackground(line reader.NumberedLine) *twin.Color {
+	side := p.diffSideIndex()
+	if side < 0 {
+		return nil
+	}
+
+	switch p.diff.lineKind(side, line) {
+	case diffLineAdded:
+		return &diffAddedBackground
+	case diffLineRemoved:
+		return &diffRemovedBackground
+	case diffLineChanged:
+		return &diffChangedBackground
+	default:
+		return nil
+	}
+}
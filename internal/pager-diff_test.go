@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+// Two files of 30 numbered lines, where the right one has line 5 changed and
+// line 20 removed
+func newDiffTestPager(t *testing.T) (*Pager, *twin.FakeScreen) {
+	leftLines := []string{}
+	rightLines := []string{}
+	for i := 1; i <= 30; i++ {
+		leftLines = append(leftLines, strconv.Itoa(i))
+		switch i {
+		case 5:
+			rightLines = append(rightLines, "five")
+		case 20:
+			// Removed
+		default:
+			rightLines = append(rightLines, strconv.Itoa(i))
+		}
+	}
+
+	screen := twin.NewFakeScreen(41, 6)
+	pager := newTestPager(t, screen,
+		reader.NewFromTextForTesting("left", strings.Join(leftLines, "\n")),
+		reader.NewFromTextForTesting("right", strings.Join(rightLines, "\n")))
+	pager.startDiff()
+	pager.diff.waitUntilCompared()
+
+	return pager, screen
+}
+
+func TestDiffSideBySide(t *testing.T) {
+	pager, screen := newDiffTestPager(t)
+	assert.Assert(t, pager.isSplit())
+	assert.Equal(t, pager.currentReader, 1, "The right file should have focus")
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "1                   │1")
+	assert.Equal(t, rowToString(screen.GetRow(4)), "5                   │five")
+
+	// Changed lines are highlighted on both sides
+	assert.Equal(t, screen.GetRow(4)[0].Style.Background(), diffChangedBackground)
+	assert.Equal(t, screen.GetRow(4)[21].Style.Background(), diffChangedBackground)
+	assert.Equal(t, screen.GetRow(3)[0].Style.Background(), twin.ColorDefault)
+
+	// Scrolling one side scrolls the other one as well
+	pager.scrollToNextChange()
+	assert.Equal(t, pager.lineIndex().Index(), 4)
+	pager.scrollToNextChange()
+	assert.Equal(t, pager.lineIndex().Index(), 19)
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "20                  │")
+	assert.Equal(t, rowToString(screen.GetRow(1)), "21                  │21")
+	assert.Equal(t, screen.GetRow(0)[21].Style.Background(), diffRemovedBackground, "Filler")
+
+	pager.scrollToNextChange()
+	assert.Equal(t, pager.mode.(*PagerModeInfo).Text, "No more changes below")
+	pager.mode = PagerModeViewing{pager: pager}
+
+	pager.scrollToPreviousChange()
+	assert.Equal(t, pager.lineIndex().Index(), 4)
+}
+
+func TestDiffGotoLine(t *testing.T) {
+	pager, _ := newDiffTestPager(t)
+
+	// Line 25 of the right file is on row 26, after the filler
+	pager.gotoLine(linemetadata.IndexFromOneBased(25))
+	assert.Equal(t, *pager.TargetLine, linemetadata.IndexFromOneBased(26))
+}
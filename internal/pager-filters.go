@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"github.com/walles/moor/v2/internal/search"
+)
+
+// All filters currently narrowing the view, oldest first
+func (p *Pager) activeFilters() []search.Search {
+	filters := []search.Search{}
+	for _, filter := range p.filterStack {
+		if filter.Active() {
+			filters = append(filters, filter)
+		}
+	}
+	if p.filter.Active() {
+		filters = append(filters, p.filter)
+	}
+	return filters
+}
+
+func (p *Pager) isFiltering() bool {
+	return len(p.activeFilters()) > 0
+}
+
+// Start editing a new filter. If we're already filtering, the new filter will
+// narrow down the current view further.
+func (p *Pager) startFiltering() {
+	stacked := false
+	if p.filter.Active() {
+		p.filterStack = append(p.filterStack, p.filter)
+		stacked = true
+	}
+	p.filter = search.Search{}
+
+	mode := NewPagerModeFilter(p)
+	mode.stackedPrevious = stacked
+	p.mode = mode
+	p.search.Clear()
+}
+
+// Remove the most recently added filter
+func (p *Pager) popFilter() {
+	if !p.isFiltering() {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Not filtering, press " + p.keymap.describeFirstKey(actionFilter) + " to start"}
+		return
+	}
+
+	if p.filter.Active() {
+		p.filter = search.Search{}
+	}
+
+	// Keep the latest filter in p.filter, so that it's the one used for
+	// highlighting and the one you get to edit
+	if len(p.filterStack) > 0 {
+		p.filter = p.filterStack[len(p.filterStack)-1]
+		p.filterStack = p.filterStack[:len(p.filterStack)-1]
+	}
+
+	if p.filter.Active() {
+		p.search = p.filter
+	} else {
+		p.search.Clear()
+	}
+}
+
+// Drop all filters. Must be called from the UI goroutine, since that's where
+// the filters are read.
+func (p *Pager) clearFilters() {
+	p.filter = search.Search{}
+	p.filterStack = nil
+}
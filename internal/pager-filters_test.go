@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func typeFilter(pager *Pager, text string) {
+	pager.startFiltering()
+	for _, char := range text {
+		pager.mode.onRune(char)
+	}
+	pager.mode.onKey(twin.KeyEnter)
+}
+
+func TestStackedFilters(t *testing.T) {
+	backing := reader.NewFromTextForTesting("test", "ab\na\nb\nabc\nc")
+	assert.NilError(t, backing.Wait())
+
+	pager := NewPager(backing)
+	pager.screen = twin.NewFakeScreen(40, 10)
+	pager.filterHistory = &SearchHistory{} // Don't touch the user's history file
+
+	typeFilter(pager, "a")
+	assert.Equal(t, pager.filteringReader.GetLineCount(), 3)
+
+	typeFilter(pager, "b")
+	assert.Equal(t, pager.filteringReader.GetLineCount(), 2, "Second filter should narrow down the first one")
+	assert.Equal(t, len(pager.filterStack), 1)
+
+	lines := pager.filteringReader.GetLines(linemetadata.Index{}, 2)
+	assert.Assert(t, strings.HasPrefix(lines.StatusText, "Filtered [a > b]: "), lines.StatusText)
+
+	pager.popFilter()
+	assert.Equal(t, pager.filteringReader.GetLineCount(), 3)
+	assert.Equal(t, pager.filter.String(), "a")
+
+	pager.popFilter()
+	assert.Assert(t, !pager.isFiltering())
+	assert.Equal(t, pager.filteringReader.GetLineCount(), 5)
+
+	assert.DeepEqual(t, pager.filterHistory.entries, []string{"a", "b"})
+}
+
+func TestCancelStackedFilter(t *testing.T) {
+	backing := reader.NewFromTextForTesting("test", "ab\na\nb")
+	assert.NilError(t, backing.Wait())
+
+	pager := NewPager(backing)
+	pager.screen = twin.NewFakeScreen(40, 10)
+	pager.filterHistory = &SearchHistory{} // Don't touch the user's history file
+
+	typeFilter(pager, "a")
+	pager.startFiltering()
+	pager.mode.onRune('b')
+	assert.Equal(t, pager.filteringReader.GetLineCount(), 1)
+
+	// Cancelling should bring back the previous filter
+	pager.mode.onKey(twin.KeyEscape)
+	assert.Equal(t, pager.filter.String(), "a")
+	assert.Equal(t, pager.search.String(), "a")
+	assert.Equal(t, len(pager.filterStack), 0)
+	assert.Equal(t, pager.filteringReader.GetLineCount(), 2)
+
+	// Cancelling the only filter stops filtering
+	pager.popFilter()
+	pager.startFiltering()
+	pager.mode.onRune('b')
+	pager.mode.onKey(twin.KeyEscape)
+	assert.Assert(t, !pager.isFiltering())
+	assert.Equal(t, pager.filteringReader.GetLineCount(), 3)
+}
+
+// Broken expressions are filtered for as-is, but the user should know why
+func TestFilterExpressionParseError(t *testing.T) {
+	backing := reader.NewFromTextForTesting("test", "ERROR && foo(bar\nERROR foo")
+	assert.NilError(t, backing.Wait())
+
+	pager := NewPager(backing)
+	pager.screen = twin.NewFakeScreen(80, 10)
+	pager.filterHistory = &SearchHistory{} // Don't touch the user's history file
+
+	typeFilter(pager, "ERROR && foo(bar")
+	assert.Equal(t, pager.filteringReader.GetLineCount(), 1)
+
+	info, isInfo := pager.mode.(*PagerModeInfo)
+	assert.Assert(t, isInfo)
+	assert.Equal(t, info.Text, "Filtering for the text as-is: unexpected \"(\" at position 13")
+
+	// Valid expressions just filter
+	pager.popFilter()
+	typeFilter(pager, "ERROR && foo")
+	assert.Equal(t, "Viewing", modeName(pager))
+	assert.Equal(t, pager.filteringReader.GetLineCount(), 2)
+}
+
+func TestFilterHistory(t *testing.T) {
+	backing := reader.NewFromTextForTesting("test", "a\nb")
+	assert.NilError(t, backing.Wait())
+
+	pager := NewPager(backing)
+	pager.screen = twin.NewFakeScreen(40, 10)
+	pager.filterHistory = &SearchHistory{entries: []string{"a", "b"}}
+
+	pager.startFiltering()
+	pager.mode.onRune('x')
+	pager.mode.onKey(twin.KeyUp)
+	assert.Equal(t, pager.filter.String(), "b")
+	pager.mode.onKey(twin.KeyUp)
+	assert.Equal(t, pager.filter.String(), "a")
+
+	// Back to what we typed
+	pager.mode.onKey(twin.KeyDown)
+	pager.mode.onKey(twin.KeyDown)
+	assert.Equal(t, pager.filter.String(), "x")
+}
@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"fmt"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+)
+
+// Highlights are removed by pressing their number, so there can be at most nine
+const maxHighlights = 9
+
+// Pick the first highlight style not already in use. If all are in use, start
+// over from the beginning.
+func (p *Pager) nextHighlightStyle() twin.Style {
+	for _, style := range highlightStyles {
+		inUse := false
+		for _, highlight := range p.highlights {
+			if highlight.Style.Equal(style) {
+				inUse = true
+				break
+			}
+		}
+
+		if !inUse {
+			return style
+		}
+	}
+
+	return highlightStyles[len(p.highlights)%len(highlightStyles)]
+}
+
+// Add the current search to the highlights, so that it stays highlighted even
+// after searching for something else.
+func (p *Pager) addSearchAsHighlight() {
+	if p.search.Inactive() {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Search for something first, then press " + p.keymap.describeFirstKey(actionAddHighlight) + " to highlight it"}
+		return
+	}
+
+	for _, highlight := range p.highlights {
+		if highlight.Pattern.Equals(p.search) {
+			p.mode = &PagerModeInfo{Pager: p, Text: fmt.Sprintf("Already highlighting %q", p.search.String())}
+			return
+		}
+	}
+
+	if len(p.highlights) >= maxHighlights {
+		p.mode = &PagerModeInfo{Pager: p, Text: fmt.Sprintf("Can't have more than %d highlights, press %s to remove some", maxHighlights, p.keymap.describeFirstKey(actionListHighlights))}
+		return
+	}
+
+	p.highlights = append(p.highlights, reader.PatternHighlight{
+		Pattern: p.search,
+		Style:   p.nextHighlightStyle(),
+	})
+	p.mode = &PagerModeInfo{Pager: p, Text: fmt.Sprintf("Highlighting %q, press %s to remove", p.search.String(), p.keymap.describeFirstKey(actionListHighlights))}
+}
+
+// Remove the highlight at the given zero based index. Out of range indices are
+// ignored.
+func (p *Pager) removeHighlight(index int) {
+	if index < 0 || index >= len(p.highlights) {
+		return
+	}
+
+	p.highlights = append(p.highlights[:index], p.highlights[index+1:]...)
+}
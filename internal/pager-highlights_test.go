@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/search"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestAddSearchAsHighlight(t *testing.T) {
+	pager := NewPager(nil)
+	pager.screen = twin.NewFakeScreen(20, 5)
+
+	pager.addSearchAsHighlight()
+	assert.Equal(t, len(pager.highlights), 0, "Nothing to highlight without a search")
+
+	pager.search = search.For("first")
+	pager.addSearchAsHighlight()
+	pager.addSearchAsHighlight()
+	assert.Equal(t, len(pager.highlights), 1, "The same search should only be highlighted once")
+
+	pager.search = search.For("second")
+	pager.addSearchAsHighlight()
+	assert.Equal(t, len(pager.highlights), 2)
+	assert.Assert(t, pager.highlights[0].Style.Equal(highlightStyles[0]))
+	assert.Assert(t, pager.highlights[1].Style.Equal(highlightStyles[1]))
+}
+
+func TestRemoveHighlightReusesStyle(t *testing.T) {
+	pager := NewPager(nil)
+	pager.screen = twin.NewFakeScreen(20, 5)
+
+	for _, pattern := range []string{"a", "b", "c"} {
+		pager.search = search.For(pattern)
+		pager.addSearchAsHighlight()
+	}
+
+	pager.removeHighlight(1)
+	assert.Equal(t, len(pager.highlights), 2)
+	assert.Equal(t, pager.highlights[0].Pattern.String(), "a")
+	assert.Equal(t, pager.highlights[1].Pattern.String(), "c")
+
+	// Out of range, should be ignored
+	pager.removeHighlight(5)
+	assert.Equal(t, len(pager.highlights), 2)
+
+	// The freed up style should be handed out next
+	pager.search = search.For("d")
+	pager.addSearchAsHighlight()
+	assert.Assert(t, pager.highlights[2].Style.Equal(highlightStyles[1]))
+}
+
+// Highlights are removed by number, so there can only be as many as there are
+// digits
+func TestTooManyHighlights(t *testing.T) {
+	pager := NewPager(nil)
+	pager.screen = twin.NewFakeScreen(20, 5)
+
+	for i := range maxHighlights + 1 {
+		pager.search = search.For(strconv.Itoa(i))
+		pager.addSearchAsHighlight()
+	}
+	assert.Equal(t, len(pager.highlights), maxHighlights)
+	assert.Equal(t, pager.mode.(*PagerModeInfo).Text, "Can't have more than 9 highlights, press '-' to remove some")
+
+	// The last one should be removable
+	PagerModeHighlights{pager: pager}.onRune('9')
+	assert.Equal(t, len(pager.highlights), maxHighlights-1)
+}
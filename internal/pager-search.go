@@ -25,6 +25,8 @@ func (p *Pager) scrollToSearchHits() {
 		return
 	}
 
+	p.recordJump()
+
 	lineIndex := p.scrollPosition.lineIndex(p)
 	if lineIndex == nil {
 		// No lines to search
@@ -76,11 +78,16 @@ func (p *Pager) scrollToNextSearchHit() {
 	}
 
 	if p.isViewing() && p.isScrolledToEnd() {
+		if p.scrollToSearchHitInOtherFile(SearchDirectionForward) {
+			return
+		}
+
 		p.mode = PagerModeNotFound{pager: p}
 		return
 	}
 
 	var firstSearchIndex linemetadata.Index
+	wrapped := false
 
 	switch {
 	case p.isViewing():
@@ -91,6 +98,7 @@ func (p *Pager) scrollToNextSearchHit() {
 		// Restart searching from the top
 		p.mode = PagerModeViewing{pager: p}
 		firstSearchIndex = linemetadata.Index{}
+		wrapped = true
 
 	default:
 		panic(fmt.Sprint("Unknown search mode when finding next: ", p.mode))
@@ -98,6 +106,10 @@ func (p *Pager) scrollToNextSearchHit() {
 
 	firstHitIndex := FindFirstHit(p.Reader(), p.search, firstSearchIndex, nil, SearchDirectionForward)
 	if firstHitIndex == nil {
+		if !wrapped && p.scrollToSearchHitInOtherFile(SearchDirectionForward) {
+			return
+		}
+
 		p.mode = PagerModeNotFound{pager: p}
 		return
 	}
@@ -112,6 +124,10 @@ func (p *Pager) scrollToNextSearchHit() {
 		p.scrollRightToSearchHits()
 	}
 	p.centerSearchHitsVertically()
+
+	if wrapped {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Search wrapped around to the top"}
+	}
 }
 
 // Scroll backwards to the previous search hit, while the user is typing the
@@ -132,6 +148,8 @@ func (p *Pager) scrollToSearchHitsBackwards() {
 		return
 	}
 
+	p.recordJump()
+
 	// Start at the top visible line
 	lineIndex := p.scrollPosition.lineIndex(p)
 
@@ -191,11 +209,16 @@ func (p *Pager) scrollToPreviousSearchHit() {
 	}
 
 	var firstSearchIndex linemetadata.Index
+	wrapped := false
 
 	switch {
 	case p.isViewing():
 		if p.scrollPosition.lineIndex(p).Index() == 0 {
 			// Already at the top, can't go further up
+			if p.scrollToSearchHitInOtherFile(SearchDirectionBackward) {
+				return
+			}
+
 			p.mode = PagerModeNotFound{pager: p}
 			return
 		}
@@ -208,6 +231,7 @@ func (p *Pager) scrollToPreviousSearchHit() {
 		// Restart searching from the bottom
 		p.mode = PagerModeViewing{pager: p}
 		firstSearchIndex = *linemetadata.IndexFromLength(p.Reader().GetLineCount())
+		wrapped = true
 
 	default:
 		panic(fmt.Sprint("Unknown search mode when finding previous: ", p.mode))
@@ -215,6 +239,10 @@ func (p *Pager) scrollToPreviousSearchHit() {
 
 	hitIndex := FindFirstHit(p.Reader(), p.search, firstSearchIndex, nil, SearchDirectionBackward)
 	if hitIndex == nil {
+		if !wrapped && p.scrollToSearchHitInOtherFile(SearchDirectionBackward) {
+			return
+		}
+
 		p.mode = PagerModeNotFound{pager: p}
 		return
 	}
@@ -229,6 +257,56 @@ func (p *Pager) scrollToPreviousSearchHit() {
 		p.scrollLeftToSearchHits()
 	}
 	p.centerSearchHitsVertically()
+
+	if wrapped {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Search wrapped around to the bottom"}
+	}
+}
+
+// Look for search hits in the files after (or before) the current one. If we
+// find one, switch to that file, scroll to the hit and return true.
+//
+// Does nothing unless SearchAcrossFiles is set.
+func (p *Pager) scrollToSearchHitInOtherFile(direction SearchDirection) bool {
+	if !p.SearchAcrossFiles || p.isShowingHelp {
+		return false
+	}
+
+	p.readerLock.Lock()
+	readers := p.readers
+	currentReader := p.currentReader
+	p.readerLock.Unlock()
+
+	step := 1
+	if direction == SearchDirectionBackward {
+		step = -1
+	}
+
+	for i := currentReader + step; i >= 0 && i < len(readers); i += step {
+		// Other files are never filtered, so we search them directly
+		r := readers[i]
+		lastLineIndex := linemetadata.IndexFromLength(r.GetLineCount())
+		if lastLineIndex == nil {
+			// No lines in this file
+			continue
+		}
+
+		var hitIndex *linemetadata.Index
+		if direction == SearchDirectionForward {
+			hitIndex = FindFirstHit(r, p.search, linemetadata.Index{}, nil, SearchDirectionForward)
+		} else {
+			hitIndex = FindFirstHit(r, p.search, *lastLineIndex, nil, SearchDirectionBackward)
+		}
+		if hitIndex == nil {
+			continue
+		}
+
+		log.Debugf("Search hit found in file %d, switching to it", i+1)
+		p.switchToFileAtLine(i, *hitIndex)
+		return true
+	}
+
+	return false
 }
 
 // Return true if any search hit is currently visible on screen.
@@ -88,6 +88,10 @@ func modeName(pager *Pager) string {
 		return "Search"
 	case *PagerModeGotoLine:
 		return "GotoLine"
+	case *PagerModeInfo:
+		return "Info"
+	case *PagerModeSelect:
+		return "Select"
 	default:
 		panic("Unknown pager mode")
 	}
@@ -149,7 +153,8 @@ func TestScrollToNextSearchHit_WrapAfterNotFound(t *testing.T) {
 	// Scroll to the next search hit, this should wrap the search and take us to
 	// the top
 	pager.scrollToNextSearchHit()
-	assert.Equal(t, "Viewing", modeName(pager))
+	assert.Equal(t, "Info", modeName(pager))
+	assert.Equal(t, "Search wrapped around to the top", pager.mode.(*PagerModeInfo).Text)
 	assert.Assert(t, pager.lineIndex().IsZero())
 }
 
@@ -168,7 +173,7 @@ func TestScrollToNextSearchHit_WrapAfterFound(t *testing.T) {
 	// Scroll to the next search hit, this should wrap the search and take us
 	// back to the bottom again
 	pager.scrollToNextSearchHit()
-	assert.Equal(t, "Viewing", modeName(pager))
+	assert.Equal(t, "Info", modeName(pager))
 	assert.Equal(t, 4, pager.lineIndex().Index())
 }
 
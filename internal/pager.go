@@ -47,16 +47,30 @@ type eventMaybeDone struct{}
 
 // Pager is the main on-screen pager
 type Pager struct {
-	readers       []*reader.ReaderImpl // Immutable since startup, no locking needed
+	readers       []*reader.ReaderImpl // Only changed by the main goroutine, holding readerLock
 	currentReader int                  // Index into the readers slice
-	readerLock    sync.Mutex           // Protects currentReader
+	readerLock    sync.Mutex           // Protects currentReader, and readers while changing
 
 	readerSwitched chan struct{}
 
 	// A view of the current reader, possibly filtered
 	filteringReader FilteringReader
 
-	screen         twin.Screen
+	// While split, this is the focused pane's part of the screen
+	screen twin.Screen
+
+	// The whole screen while split, nil otherwise
+	fullScreen twin.Screen
+
+	// Split screen panes, empty when not split. The focused pane's entry is
+	// outdated, its state is in the Pager itself. See panes.go.
+	panes           []paneState
+	focusedPane     int
+	splitVertically bool
+
+	// Set while comparing files, see pager-diff.go
+	diff *fileDiff
+
 	quit           bool
 	scrollPosition scrollPosition
 
@@ -74,14 +88,41 @@ type Pager struct {
 	// This should never be null while paging. Configured in NewPager().
 	searchHistory *SearchHistory
 
+	// The filter being edited, or the last one added
 	filter search.Search
 
+	// Older filters, applied before p.filter. Each one narrows down the view
+	// further.
+	filterStack []search.Search
+
+	// This should never be null while paging. Configured in NewPager().
+	filterHistory *SearchHistory
+
+	// For the ':' command line. Configured in NewPager().
+	commandHistory *SearchHistory
+
+	// Lines to show around each filter match
+	filterContext FilterContext
+
+	// For showing "line 12/340 with hits" in the status bar
+	searchHitCounter searchHitCounter
+
+	// Patterns highlighted in their own colors, in addition to the search.
+	// These stay when switching between files.
+	highlights []reader.PatternHighlight
+
 	// We used to have a "Following" field here. If you want to follow, set
 	// TargetLineNumber to linemetadata.IndexMax() instead, see below.
 
 	isShowingHelp bool
 	preHelpState  *_PreHelpState
 
+	// What the keys do while viewing. Configured in NewPager().
+	keymap Keymap
+
+	// Generated from the keymap, see SetKeymap()
+	helpReader *reader.ReaderImpl
+
 	// User preference
 	ShowLineNumbers bool
 
@@ -101,6 +142,10 @@ type Pager struct {
 	// Search for this string on startup, ignored if empty
 	InitialSearch string
 
+	// If set, shown in the status bar when paging starts. For problems that
+	// shouldn't stop us from paging, like config file errors.
+	StartupWarning string
+
 	// Ref: https://github.com/walles/moor/issues/94
 	ScrollLeftHint  textstyles.CellWithMetadata
 	ScrollRightHint textstyles.CellWithMetadata
@@ -130,6 +175,18 @@ type Pager struct {
 	// actual hits)
 	WithSearchHitLineBackground bool
 
+	// If true, 'n' / 'p' continue searching in the next / previous file when
+	// there are no more hits in the current one
+	SearchAcrossFiles bool
+
+	// If true, show the first two files side by side, highlighting the
+	// differences between them. Requires exactly two files.
+	Diff bool
+
+	// How to read files opened while paging, like with ':e file.txt'. If nil,
+	// those files get the default reader options and no file settings.
+	OptionsForFile func(fileName string) (reader.ReaderOptions, FileSettings)
+
 	// Length of the longest line displayed. This is used for limiting scrolling
 	// to the right.
 	longestLineLength int
@@ -141,6 +198,24 @@ type Pager struct {
 
 	// Maximum width instead of reported screen width
 	Width int
+
+	// Where the left mouse button was pressed, while it's held down
+	dragStart *linemetadata.Index
+
+	// The cursor line as an index into p.Reader(), or nil if the cursor line
+	// is turned off. See cursor.go.
+	cursor *linemetadata.Index
+
+	// Positions to go back to after big jumps, per file. See jumps.go.
+	jumpLists map[*reader.ReaderImpl]*jumpList
+
+	// Per file overrides of the settings above, see SetFileSettings()
+	fileSettings     map[*reader.ReaderImpl]FileSettings
+	baseFileSettings baseFileSettings
+
+	// For highlighting readers created while paging. Set by StartPaging().
+	chromaStyle     *chroma.Style
+	chromaFormatter *chroma.Formatter
 }
 
 type _PreHelpState struct {
@@ -149,75 +224,6 @@ type _PreHelpState struct {
 	targetLine          *linemetadata.Index
 }
 
-var _HelpReader = reader.NewFromTextForTesting("Help", `
-Welcome to Moor, the nice pager!
-
-Miscellaneous
--------------
-* Press 'q' or 'ESC' to quit
-* Press 'w' to toggle wrapping of long lines
-* Press '=' to toggle showing the status bar at the bottom
-* Press 'v' to edit the file in your favorite editor
-* Press CTRL-t to change the tab size
-* Press 'r' to reload the current file
-
-Moving around
--------------
-* Arrow keys
-* Alt key plus left / right arrow steps one column at a time
-* Left / right can be used to hide / show line numbers
-* Home and End for start / end of the document
-* 'g' for going to a specific line number
-* 'm' sets a mark, you will be asked for a letter to label it with
-* ' (single quote) jumps to the mark
-* CTRL-p moves to the previous line
-* CTRL-n moves to the next line
-* PageUp / 'b' and PageDown / 'f'
-* SPACE moves down a page
-* < / 'gg' to go to the start of the document
-* > / 'G' to go to the end of the document
-* Half page 'u'p / 'd'own, or CTRL-u / CTRL-d
-* CTRL-a moves to the leftmost position
-* RETURN moves down one line
-
-Switching files (if you opened multiple files)
-----------------------------------------------
-* Press ':' to enter file switching mode
-
-Filtering
----------
-Type '&' to start filtering, then type your filter expression.
-
-While filtering, arrow keys, PageUp, PageDown, Home and End work as usual.
-
-Press 'ESC' or RETURN to exit filtering mode.
-
-Searching
----------
-* Type / to start searching, then type what you want to find
-* Type ? to search backwards, then type what you want to find
-* Type RETURN to stop searching, or ESC to skip back to where the search started
-* Press up / down arrows while searching to access search history
-* Find next by typing 'n' (for "next")
-* Find previous by typing SHIFT-N or 'p' (for "previous")
-* Search is case sensitive if it contains any UPPER CASE CHARACTERS
-* Search is interpreted as a regexp if it is a valid one
-
-Reporting bugs
---------------
-File issues at https://github.com/walles/moor/issues, or post
-questions to johan.walles@gmail.com.
-
-Installing Moor as your default pager
--------------------------------------
-Put the following line in your ~/.bashrc, ~/.bash_profile or ~/.zshrc:
-  export PAGER=moor
-
-Source Code
------------
-Available at https://github.com/walles/moor/.
-`)
-
 // NewPager creates a new Pager with default settings
 func NewPager(readers ...*reader.ReaderImpl) *Pager {
 	if len(readers) == 0 {
@@ -253,11 +259,21 @@ func NewPager(readers ...*reader.ReaderImpl) *Pager {
 	pager.filteringReader = FilteringReader{
 		BackingReader: readers[0], // Always start with the first reader
 		Filter:        &pager.filter,
+		Stack:         &pager.filterStack,
+		Context:       &pager.filterContext,
 	}
 
 	searchHistory := BootSearchHistory("")
 	pager.searchHistory = &searchHistory
 
+	filterHistory := BootFilterHistory("")
+	pager.filterHistory = &filterHistory
+
+	commandHistory := BootCommandHistory("")
+	pager.commandHistory = &commandHistory
+
+	pager.SetKeymap(newDefaultKeymap())
+
 	return &pager
 }
 
@@ -272,6 +288,11 @@ func (p *Pager) ScreenSize() (int, linemetadata.ScreenLines) {
 func (p *Pager) visibleHeight() linemetadata.ScreenLines {
 	_, height := p.ScreenSize()
 
+	if occur, ok := p.mode.(*PagerModeOccur); ok {
+		// The list of hits goes below the document
+		return height - 1 - occur.listHeight()
+	}
+
 	// Only the viewing mode can be without status bar
 	hasStatusBar := p.ShowStatusBar || !p.isViewing()
 
@@ -414,44 +435,39 @@ func (p *Pager) moveRight(delta int) {
 
 func (p *Pager) Reader() reader.Reader {
 	if p.isShowingHelp {
-		return _HelpReader
+		return p.helpReader
 	}
 	return &p.filteringReader
 }
 
+// Re-read the current file from disk, trying to stay at the same position.
+// Does nothing for streams.
 func (p *Pager) ReloadCurrentReader() {
 	p.readerLock.Lock()
-	defer p.readerLock.Unlock()
-
 	current := p.readers[p.currentReader]
-	if !current.ReadingDone.Load() || !current.HighlightingDone.Load() {
-		// The reader's formatting options (like the Style) are fully populated only
-		// once the initial read/highlighting pass consumes them from its channels.
-		// If we clone the reader before this happens, we copy a nil style, and the
-		// cloned reader will deadlock forever waiting for a style it will never receive.
-		//
-		// So let's just ignore this request. This should clear up quickly, and
-		// if the user presses 'r' again the reload will go through.
-		return
-	}
+	p.readerLock.Unlock()
 
-	clone, err := current.Clone()
-	if err != nil {
-		log.Warnf("Failed to clone reader for reloading: %v", err)
+	if !current.Reload() {
+		// Not a file, nothing to reload
 		return
 	}
-	if clone == nil {
-		// Nil indicates it's a stream or couldn't be cloned
+
+	p.stayAtCurrentLineAcrossReload()
+}
+
+// Call after asking the current reader to reload
+func (p *Pager) stayAtCurrentLineAcrossReload() {
+	if p.TargetLine != nil || p.isShowingHelp {
+		// Already going somewhere, or the current position is in the help
+		// text. Either way, don't touch it.
 		return
 	}
 
-	current.Close()
-	p.readers[p.currentReader] = clone
-	p.filteringReader.SetBackingReader(clone)
-
-	select {
-	case p.readerSwitched <- struct{}{}:
-	default:
+	// The reload starts with an empty reader, so the current position will
+	// go away. Come back to it once the new contents are in.
+	lineIndex := p.scrollPosition.lineIndex(p)
+	if lineIndex != nil {
+		p.setTargetLine(lineIndex)
 	}
 }
 
@@ -504,6 +520,10 @@ func (p *Pager) setTargetLine(targetLine *linemetadata.Index) {
 
 	log.Trace("Pager: Setting target line to ", targetLine, "...")
 	p.TargetLine = targetLine
+	if p.diff.sideOf(r) >= 0 {
+		// Files being compared are read all the way, see startDiff()
+		return
+	}
 	if targetLine == nil {
 		// No target, just do your thing
 		r.SetPauseAfterLines(reader.DEFAULT_PAUSE_AFTER_LINES)
@@ -536,24 +556,27 @@ func (p *Pager) StartPaging(screen twin.Screen, chromaStyle *chroma.Style, chrom
 		}
 	}()
 
-	p.showLineNumbers = p.ShowLineNumbers
+	p.readerLock.Lock()
+	p.applyFileSettings(nil, p.readers[p.currentReader])
+	p.readerLock.Unlock()
 
 	textstyles.UnprintableStyle = p.UnprintableStyle
-	if p.TabSize > 0 {
-		// "0" = unset, stay at the default. If the tab size is negative, just
-		// ignoring it seems like the right move.
-		textstyles.TabSize = p.TabSize
-	}
 	consumeLessTermcapEnvs(screen.TerminalBackground(), chromaStyle, chromaFormatter)
 	styleUI(screen.TerminalBackground(), chromaStyle, chromaFormatter, p.StatusBarStyle, p.WithTerminalFg, p.WithSearchHitLineBackground)
 
 	p.screen = screen
 	p.mode = PagerModeViewing{pager: p}
 	p.bookmarks = make(map[rune]scrollPosition)
+	p.chromaStyle = chromaStyle
+	p.chromaFormatter = chromaFormatter
 
 	// Make sure the reader knows how many lines we want
 	p.setTargetLine(p.TargetLine)
 
+	if p.Diff {
+		p.startDiff()
+	}
+
 	if p.InitialSearch != "" {
 		// Trigger the initial search as if the user pressed "/", typed a query and pressed Enter
 
@@ -563,6 +586,10 @@ func (p *Pager) StartPaging(screen twin.Screen, chromaStyle *chroma.Style, chrom
 		p.scrollToSearchHits()
 	}
 
+	if p.StartupWarning != "" {
+		p.mode = &PagerModeInfo{Pager: p, Text: p.StartupWarning}
+	}
+
 	go func() {
 		defer func() {
 			PanicHandler("StartPaging()/goroutine", recover(), debug.Stack())
@@ -585,14 +612,21 @@ func (p *Pager) StartPaging(screen twin.Screen, chromaStyle *chroma.Style, chrom
 			r := p.readers[p.currentReader]
 			p.readerLock.Unlock()
 
+			// While comparing files, the other file being done changes what
+			// we show as well, and so does the comparison finishing
+			var otherMaybeDone chan bool
+			var diffCompared chan bool
+			if side := p.diff.sideOf(r); side >= 0 {
+				otherMaybeDone = p.diff.readers[1-side].MaybeDone
+				diffCompared = p.diff.compared
+			}
+
 			select {
 			case <-p.readerSwitched:
 				// A different reader is now active
-				p.filter = search.Search{}
-
 				p.readerLock.Lock()
 				r = p.readers[p.currentReader]
-				p.filteringReader.SetBackingReader(r)
+				p.filteringReader.SetBackingReader(p.viewOf(r))
 				p.readerLock.Unlock()
 
 				// Look in the right place for more lines
@@ -640,6 +674,12 @@ func (p *Pager) StartPaging(screen twin.Screen, chromaStyle *chroma.Style, chrom
 
 			case <-r.MaybeDone:
 				screen.Events() <- eventMaybeDone{}
+
+			case <-otherMaybeDone:
+				screen.Events() <- eventMaybeDone{}
+
+			case <-diffCompared:
+				screen.Events() <- eventMaybeDone{}
 			}
 		}
 	}()
@@ -709,6 +749,9 @@ func (p *Pager) StartPaging(screen twin.Screen, chromaStyle *chroma.Style, chrom
 
 			case twin.MouseWheelRight:
 				p.moveRight(p.SideScrollAmount)
+
+			default:
+				p.onMouseSelect(event)
 			}
 
 		case twin.EventResize:
@@ -737,6 +780,9 @@ func (p *Pager) StartPaging(screen twin.Screen, chromaStyle *chroma.Style, chrom
 		case eventSpinnerUpdate:
 			spinner = event.spinner
 
+		case eventSearchHitsCounted:
+			// We'll be implicitly redrawn just by taking another lap in the loop
+
 		default:
 			log.Warnf("Unhandled event type: %v", event)
 		}
@@ -814,7 +860,7 @@ func (p *Pager) fitsOnOneScreen() bool {
 
 	lines := reader.GetLines(linemetadata.Index{}, reader.GetLineCount())
 	for _, line := range lines.Lines {
-		rendered := line.HighlightedTokens(twin.StyleDefault, twin.StyleDefault, search.Search{}, width+1).StyledRunes
+		rendered := line.HighlightedTokens(twin.StyleDefault, twin.StyleDefault, search.Search{}, nil, width+1).StyledRunes
 		if len(rendered) > width {
 			// This line is too long to fit on one screen line, no fit
 			return false
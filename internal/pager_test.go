@@ -5,6 +5,7 @@ import (
 	"os"
 	"path"
 	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -98,6 +99,41 @@ func TestBrokenUtf8(t *testing.T) {
 	}
 }
 
+// Set up a pager showing the readers on the screen, without line numbers.
+// Unlike the startPaging*() functions, nothing is rendered, so that tests can
+// change settings or press keys first.
+func newTestPager(t *testing.T, screen *twin.FakeScreen, readers ...*reader.ReaderImpl) *Pager {
+	for _, reader := range readers {
+		err := reader.Wait()
+		if err != nil {
+			t.Fatalf("Failed waiting for reader: %v", err)
+		}
+	}
+
+	pager := NewPager(readers...)
+	pager.screen = screen
+	pager.mode = PagerModeViewing{pager: pager}
+	pager.bookmarks = map[rune]scrollPosition{}
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+
+	// Don't touch the user's history files
+	pager.searchHistory = &SearchHistory{}
+	pager.filterHistory = &SearchHistory{}
+	pager.commandHistory = &SearchHistory{}
+
+	return pager
+}
+
+// Lines like "line 1", "line 2" and so on, or "1", "2"... with an empty prefix
+func numberedLines(prefix string, count int) string {
+	lines := []string{}
+	for i := range count {
+		lines = append(lines, prefix+strconv.Itoa(i+1))
+	}
+	return strings.Join(lines, "\n")
+}
+
 func startPaging(t *testing.T, reader *reader.ReaderImpl) *twin.FakeScreen {
 	// 0 means default tab size. Defaults to 8 to be like less.
 	return startPagingWithTabSizeAndScreen(t, 0, twin.NewFakeScreen(20, 10), reader)
@@ -700,3 +736,17 @@ func TestHandleMoreLinesAvailableWithEmptyFile(t *testing.T) {
 		t.Error("Expected TargetLine to remain set when no lines available")
 	}
 }
+
+func TestStartupWarning(t *testing.T) {
+	reader := reader.NewFromTextForTesting("", "hello")
+	assert.NilError(t, reader.Wait())
+
+	pager := NewPager(reader)
+	pager.StartupWarning = "Config file problem: oops"
+
+	// Tell our Pager to quit immediately
+	pager.Quit()
+
+	pager.StartPaging(twin.NewFakeScreen(40, 10), nil, nil)
+	assert.Equal(t, pager.mode.(*PagerModeInfo).Text, "Config file problem: oops")
+}
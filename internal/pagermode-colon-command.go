@@ -6,62 +6,149 @@ import (
 )
 
 type PagerModeColonCommand struct {
-	pager *Pager
+	pager    *Pager
+	inputBox InputBox
+
+	commandHistoryIndex int
+	userEditedText      string
+
+	// Set while pressing TAB repeatedly to cycle through completions
+	completions     []string
+	completionIndex int
+}
+
+func NewPagerModeColonCommand(p *Pager) *PagerModeColonCommand {
+	return &PagerModeColonCommand{
+		pager: p,
+		inputBox: InputBox{
+			accept: INPUTBOX_ACCEPT_ALL,
+		},
+		commandHistoryIndex: len(p.commandHistory.entries), // Past the end
+	}
 }
 
 func (m *PagerModeColonCommand) drawFooter(_ string, _ string, _ string) {
-	p := m.pager
-	_, screenHeight := p.ScreenSize()
-	height := int(screenHeight)
+	m.inputBox.draw(m.pager.screen, "'TAB' completes, 'ENTER' runs, 'ESC' cancels, '↑↓' navigate history", ":")
+}
+
+func (m *PagerModeColonCommand) moveCommandHistoryIndex(delta int) {
+	history := m.pager.commandHistory
+	if len(history.entries) == 0 {
+		return
+	}
+
+	m.commandHistoryIndex += delta
+	if m.commandHistoryIndex < 0 {
+		m.commandHistoryIndex = 0
+	}
+	if m.commandHistoryIndex > len(history.entries) {
+		m.commandHistoryIndex = len(history.entries) // Beyond the end of the history
+	}
 
-	pos := 0
-	for _, token := range "Go to [n]ext, [p]revious or first [x] file: " {
-		pos += p.screen.SetCell(pos, height-1, twin.NewStyledRune(token, twin.StyleDefault))
+	if m.commandHistoryIndex == len(history.entries) {
+		// Reset to whatever the user typed last
+		m.inputBox.setText(m.userEditedText)
+	} else {
+		m.inputBox.setText(history.entries[m.commandHistoryIndex])
 	}
+	m.completions = nil
+}
+
+// Complete as far as all completions agree. If that doesn't change anything,
+// step through the completions one by one.
+func (m *PagerModeColonCommand) complete() {
+	if m.completions != nil {
+		m.completionIndex = (m.completionIndex + 1) % len(m.completions)
+		m.inputBox.setText(m.completions[m.completionIndex])
+		return
+	}
+
+	completions := m.pager.completeColonCommand(m.inputBox.text)
+	if len(completions) == 0 {
+		log.Debugf("No completions for %q", m.inputBox.text)
+		return
+	}
+
+	prefix := commonPrefix(completions)
+	if len(completions) > 1 && prefix == m.inputBox.text {
+		m.completions = completions
+		m.completionIndex = 0
+		prefix = completions[0]
+	}
+	m.inputBox.setText(prefix)
+}
+
+func commonPrefix(texts []string) string {
+	prefix := []rune(texts[0])
+	for _, text := range texts[1:] {
+		runes := []rune(text)
+		length := 0
+		for length < len(prefix) && length < len(runes) && prefix[length] == runes[length] {
+			length++
+		}
+		prefix = prefix[:length]
+	}
+	return string(prefix)
+}
 
-	// Add a cursor
-	p.screen.SetCell(pos, height-1, twin.NewStyledRune(' ', twin.StyleDefault.WithAttr(twin.AttrReverse)))
+func (m *PagerModeColonCommand) userEdited() {
+	m.commandHistoryIndex = len(m.pager.commandHistory.entries) // Reset history index when user types
+	m.userEditedText = m.inputBox.text
+	m.completions = nil
+}
+
+func (m *PagerModeColonCommand) run() {
+	p := m.pager
+	p.commandHistory.addEntry(m.inputBox.text)
+
+	// Commands may switch to other modes
+	p.mode = PagerModeViewing{pager: p}
+
+	err := p.runColonCommand(m.inputBox.text)
+	if err != nil {
+		log.Debugf("Command %q failed: %v", m.inputBox.text, err)
+		p.mode = &PagerModeInfo{Pager: p, Text: err.Error()}
+	}
 }
 
 func (m *PagerModeColonCommand) onKey(key twin.KeyCode) {
 	p := m.pager
 
+	if m.inputBox.handleKey(key) {
+		m.userEdited()
+		return
+	}
+
 	switch key {
+	case twin.KeyEnter:
+		m.run()
+
 	case twin.KeyEscape:
 		p.mode = PagerModeViewing{pager: p}
 
+	case twin.KeyUp:
+		m.moveCommandHistoryIndex(-1)
+
+	case twin.KeyDown:
+		m.moveCommandHistoryIndex(1)
+
 	default:
-		log.Tracef("Unhandled colon command event %v, treating as a viewing key event", key)
-		p.mode = PagerModeViewing{pager: p}
-		p.mode.onKey(key)
+		log.Debugf("Unhandled colon command key event %v", key)
 	}
 }
 
 func (m *PagerModeColonCommand) onRune(char rune) {
 	p := m.pager
 
-	if char == 'q' {
-		// Back to viewing mode, just like ESC
-		p.mode = PagerModeViewing{pager: p}
-	}
-
-	if char == 'p' {
-		p.mode = PagerModeViewing{pager: p}
-		p.previousFile()
-		return
-	}
+	switch char {
+	case '\t':
+		m.complete()
 
-	if char == 'n' {
+	case '\x03': // CTRL-c
 		p.mode = PagerModeViewing{pager: p}
-		p.nextFile()
-		return
-	}
 
-	if char == 'x' {
-		p.mode = PagerModeViewing{pager: p}
-		p.firstFile()
-		return
+	default:
+		m.inputBox.handleRune(char)
+		m.userEdited()
 	}
-
-	log.Debugf("Unhandled colon command rune %q, ignoring it", char)
 }
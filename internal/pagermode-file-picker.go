@@ -0,0 +1,309 @@
+// List all open files at the bottom of the screen, narrow the list down by
+// typing, and switch to the selected file.
+
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+)
+
+type pickableFile struct {
+	readerIndex int
+	name        string
+	reader      *reader.ReaderImpl
+
+	// Rune indices into the name matching what the user typed
+	matched []int
+}
+
+type PagerModeFilePicker struct {
+	pager    *Pager
+	inputBox *InputBox
+
+	// All open files, in the order they were opened
+	files []pickableFile
+
+	// The files matching what the user typed, best match first
+	matches []pickableFile
+
+	// Index into the matches
+	selected int
+
+	// The first match shown in the list
+	firstListed int
+}
+
+// Enter the file picker, or explain why not
+func (p *Pager) startPickingFile() {
+	p.readerLock.Lock()
+	readers := p.readers
+	currentReader := p.currentReader
+	p.readerLock.Unlock()
+
+	if len(readers) < 2 {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Only one file open, open more by typing ':e' and a file name"}
+		return
+	}
+
+	m := &PagerModeFilePicker{pager: p}
+	for i, r := range readers {
+		name := fmt.Sprintf("file %d", i+1)
+		if r.DisplayName != nil {
+			name = *r.DisplayName
+		}
+		m.files = append(m.files, pickableFile{readerIndex: i, name: name, reader: r})
+	}
+	m.inputBox = &InputBox{
+		accept: INPUTBOX_ACCEPT_ALL,
+		onTextChanged: func(text string) {
+			m.updateMatches(text)
+		},
+	}
+
+	m.updateMatches("")
+	m.selected = currentReader
+
+	p.mode = m
+}
+
+// Narrow the list down to the files matching the text
+func (m *PagerModeFilePicker) updateMatches(text string) {
+	m.matches = []pickableFile{}
+	scores := map[int]int{}
+	for _, file := range m.files {
+		matched, score, ok := fuzzyMatch(text, file.name)
+		if !ok {
+			continue
+		}
+
+		file.matched = matched
+		scores[file.readerIndex] = score
+		m.matches = append(m.matches, file)
+	}
+
+	slices.SortStableFunc(m.matches, func(a, b pickableFile) int {
+		return scores[a.readerIndex] - scores[b.readerIndex]
+	})
+
+	m.selected = 0
+	m.firstListed = 0
+}
+
+// Match if all pattern characters are in the text, in order. Matched rune
+// indices are returned together with a score, lower is better. Matching is
+// case insensitive.
+func fuzzyMatch(pattern string, text string) ([]int, int, bool) {
+	patternRunes := []rune(strings.ToLower(pattern))
+	matched := []int{}
+	score := 0
+
+	i := 0
+	for textIndex, char := range []rune(strings.ToLower(text)) {
+		if i == len(patternRunes) {
+			break
+		}
+		if char != patternRunes[i] {
+			continue
+		}
+
+		if len(matched) == 0 {
+			// Matches further in are worse
+			score += textIndex
+		} else if textIndex != matched[len(matched)-1]+1 {
+			// Gaps are worse than consecutive matches
+			score += 10
+		}
+		matched = append(matched, textIndex)
+		i++
+	}
+
+	if i < len(patternRunes) {
+		return nil, 0, false
+	}
+	return matched, score, true
+}
+
+// How many files are listed at the bottom of the screen
+func (m *PagerModeFilePicker) listHeight() int {
+	_, height := m.pager.ScreenSize()
+
+	// Leave at least half of the screen for the document
+	listHeight := min(len(m.matches), int(height-1)/2)
+	if listHeight < 1 {
+		listHeight = 1
+	}
+
+	return listHeight
+}
+
+func (m *PagerModeFilePicker) moveSelection(delta int) {
+	m.selected += delta
+	m.clampSelection()
+}
+
+// Keep the selection within the matches, and the list scrolled so that the
+// selection is visible
+func (m *PagerModeFilePicker) clampSelection() {
+	if m.selected >= len(m.matches) {
+		m.selected = len(m.matches) - 1
+	}
+	if m.selected < 0 {
+		m.selected = 0
+	}
+
+	listHeight := m.listHeight()
+	if m.selected < m.firstListed {
+		m.firstListed = m.selected
+	}
+	if m.selected >= m.firstListed+listHeight {
+		m.firstListed = m.selected - listHeight + 1
+	}
+}
+
+// Like "1234 lines, compressed, highlighting..."
+func describeFile(r *reader.ReaderImpl) string {
+	lineCount := r.GetLineCount()
+	description := fmt.Sprintf("%d lines", lineCount)
+	if lineCount == 1 {
+		description = "1 line"
+	}
+
+	if r.IsCompressed {
+		description += ", compressed"
+	}
+
+	if !r.ReadingDone.Load() {
+		description += ", reading..."
+	} else if !r.HighlightingDone.Load() {
+		description += ", highlighting..."
+	}
+
+	return description
+}
+
+func (m *PagerModeFilePicker) drawFooter(_ string, _ string, _ string) {
+	p := m.pager
+
+	width, height := p.screen.Size()
+	listHeight := m.listHeight()
+	firstRow := height - 1 - listHeight
+
+	// Keep the selection visible even if the screen was resized
+	m.clampSelection()
+
+	numberWidth := len(fmt.Sprint(len(m.files)))
+	nameWidth := 0
+	for _, file := range m.matches {
+		nameWidth = max(nameWidth, len([]rune(file.name)))
+	}
+
+	for row := 0; row < listHeight; row++ {
+		screenRow := firstRow + row
+		for column := 0; column < width; column++ {
+			p.screen.SetCell(column, screenRow, twin.NewStyledRune(' ', twin.StyleDefault))
+		}
+
+		if m.firstListed+row >= len(m.matches) {
+			if row == 0 {
+				for column, token := range []rune("No matching files") {
+					p.screen.SetCell(column, screenRow, twin.NewStyledRune(token, lineNumbersStyle))
+				}
+			}
+			continue
+		}
+		file := m.matches[m.firstListed+row]
+
+		numberStyle := lineNumbersStyle
+		if m.firstListed+row == m.selected {
+			numberStyle = statusbarStyle
+		}
+
+		column := 0
+		for _, token := range fmt.Sprintf("%*d ", numberWidth, file.readerIndex+1) {
+			column += p.screen.SetCell(column, screenRow, twin.NewStyledRune(token, numberStyle))
+		}
+
+		for i, token := range []rune(fmt.Sprintf("%-*s  ", nameWidth, file.name)) {
+			style := twin.StyleDefault
+			if slices.Contains(file.matched, i) {
+				style = style.WithAttr(twin.AttrBold).WithAttr(twin.AttrUnderline)
+			}
+			column += p.screen.SetCell(column, screenRow, twin.NewStyledRune(token, style))
+		}
+
+		for _, token := range describeFile(file.reader) {
+			if column >= width {
+				break
+			}
+			column += p.screen.SetCell(column, screenRow, twin.NewStyledRune(token, lineNumbersStyle))
+		}
+	}
+
+	m.inputBox.draw(p.screen, "Type to narrow down, '↑↓' select, 'ENTER' switches, 'ESC' cancels", "Switch to file: ")
+}
+
+func (m *PagerModeFilePicker) switchToSelected() {
+	p := m.pager
+	p.mode = PagerModeViewing{pager: p}
+
+	if len(m.matches) == 0 {
+		return
+	}
+
+	p.goToFile(m.matches[m.selected].readerIndex)
+}
+
+func (m *PagerModeFilePicker) onKey(key twin.KeyCode) {
+	p := m.pager
+
+	if m.inputBox.handleKey(key) {
+		return
+	}
+
+	switch key {
+	case twin.KeyUp:
+		m.moveSelection(-1)
+
+	case twin.KeyDown:
+		m.moveSelection(1)
+
+	case twin.KeyPgUp:
+		m.moveSelection(-m.listHeight())
+
+	case twin.KeyPgDown:
+		m.moveSelection(m.listHeight())
+
+	case twin.KeyEnter:
+		m.switchToSelected()
+
+	case twin.KeyEscape:
+		p.mode = PagerModeViewing{pager: p}
+
+	default:
+		log.Debugf("Unhandled file picker key event %v", key)
+	}
+}
+
+func (m *PagerModeFilePicker) onRune(char rune) {
+	p := m.pager
+
+	switch char {
+	case '\x03': // CTRL-c
+		p.mode = PagerModeViewing{pager: p}
+
+	case '\x10': // CTRL-p
+		m.moveSelection(-1)
+
+	case '\x0e': // CTRL-n
+		m.moveSelection(1)
+
+	default:
+		m.inputBox.handleRune(char)
+	}
+}
@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	matched, _, ok := fuzzyMatch("alg", "app.log.gz")
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, matched, []int{0, 4, 6})
+
+	_, _, ok = fuzzyMatch("gla", "app.log.gz")
+	assert.Assert(t, !ok)
+
+	_, _, ok = fuzzyMatch("LOG", "app.log")
+	assert.Assert(t, ok, "Matching should be case insensitive")
+
+	_, consecutive, _ := fuzzyMatch("log", "log.txt")
+	_, gaps, _ := fuzzyMatch("log", "l.o.g")
+	_, later, _ := fuzzyMatch("log", "app.log")
+	assert.Assert(t, consecutive < later)
+	assert.Assert(t, later < gaps)
+}
+
+func TestFilePicker(t *testing.T) {
+	pager := NewPager(
+		reader.NewFromTextForTesting("main.go", "package main"),
+		reader.NewFromTextForTesting("app.log", "hello"),
+		reader.NewFromTextForTesting("app.log.1", "older"),
+	)
+	pager.screen = twin.NewFakeScreen(40, 10)
+	pager.mode = PagerModeViewing{pager: pager}
+
+	pager.mode.onRune('B')
+	mode := pager.mode.(*PagerModeFilePicker)
+	assert.Equal(t, len(mode.matches), 3)
+	assert.Equal(t, mode.selected, 0, "Should start at the current file")
+
+	for _, char := range "log1" {
+		mode.onRune(char)
+	}
+	assert.Equal(t, len(mode.matches), 1)
+	assert.Equal(t, mode.matches[0].name, "app.log.1")
+
+	mode.drawFooter("", "", "")
+
+	mode.onKey(twin.KeyEnter)
+	assert.Equal(t, pager.currentReader, 2)
+	assert.Equal(t, pager.mode, PagerModeViewing{pager: pager})
+}
+
+func TestFilePickerSingleFile(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("main.go", "package main"))
+	pager.screen = twin.NewFakeScreen(40, 10)
+
+	pager.startPickingFile()
+	_, isInfo := pager.mode.(*PagerModeInfo)
+	assert.Assert(t, isInfo)
+}
@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/twin"
+)
+
+// Asks for how many lines of context to show around filter matches
+type PagerModeFilterContext struct {
+	pager    *Pager
+	inputBox InputBox
+}
+
+func NewPagerModeFilterContext(p *Pager) *PagerModeFilterContext {
+	return &PagerModeFilterContext{
+		pager: p,
+		inputBox: InputBox{
+			accept: INPUTBOX_ACCEPT_ALL,
+		},
+	}
+}
+
+func (m *PagerModeFilterContext) drawFooter(_ string, _ string, _ string) {
+	m.inputBox.draw(m.pager.screen, "'ENTER' submits, 'ESC' cancels", "Filter context lines, N or BEFORE,AFTER: ")
+}
+
+// Parses "3" into 3 lines before and after, and "1,2" into 1 line before and 2
+// after
+func parseFilterContext(text string) (FilterContext, error) {
+	beforeText, afterText, found := strings.Cut(text, ",")
+	if !found {
+		afterText = beforeText
+	}
+
+	before, err := strconv.Atoi(strings.TrimSpace(beforeText))
+	if err != nil || before < 0 {
+		return FilterContext{}, fmt.Errorf("not a line count: %q", beforeText)
+	}
+
+	after, err := strconv.Atoi(strings.TrimSpace(afterText))
+	if err != nil || after < 0 {
+		return FilterContext{}, fmt.Errorf("not a line count: %q", afterText)
+	}
+
+	return FilterContext{Before: before, After: after}, nil
+}
+
+func (m *PagerModeFilterContext) onKey(key twin.KeyCode) {
+	p := m.pager
+
+	if m.inputBox.handleKey(key) {
+		return
+	}
+
+	switch key {
+	case twin.KeyEnter:
+		context, err := parseFilterContext(m.inputBox.text)
+		if err != nil {
+			p.mode = &PagerModeInfo{Pager: p, Text: "Filter context: " + err.Error()}
+			return
+		}
+
+		p.filterContext = context
+		p.mode = &PagerModeInfo{Pager: p, Text: describeFilterContext(context)}
+
+	case twin.KeyEscape:
+		p.mode = PagerModeViewing{pager: p}
+
+	default:
+		log.Debugf("Unhandled filter context key event %v", key)
+	}
+}
+
+func (m *PagerModeFilterContext) onRune(char rune) {
+	m.inputBox.handleRune(char)
+}
+
+func describeFilterContext(context FilterContext) string {
+	if context == (FilterContext{}) {
+		return "Filter context disabled"
+	}
+
+	return fmt.Sprintf("Filter context: %d line(s) before and %d after each match", context.Before, context.After)
+}
+
+// Step through some common context sizes
+func (p *Pager) cycleFilterContext() {
+	switch {
+	case p.filterContext.Before != p.filterContext.After:
+		p.filterContext = FilterContext{}
+	case p.filterContext.Before == 0:
+		p.filterContext = FilterContext{Before: 1, After: 1}
+	case p.filterContext.Before < 3:
+		p.filterContext = FilterContext{Before: p.filterContext.Before + 1, After: p.filterContext.After + 1}
+	case p.filterContext.Before < 5:
+		p.filterContext = FilterContext{Before: 5, After: 5}
+	default:
+		p.filterContext = FilterContext{}
+	}
+}
@@ -1,6 +1,9 @@
 package internal
 
 import (
+	"fmt"
+	"strings"
+
 	log "github.com/sirupsen/logrus"
 	"github.com/walles/moor/v2/internal/search"
 	"github.com/walles/moor/v2/twin"
@@ -9,11 +12,21 @@ import (
 type PagerModeFilter struct {
 	pager    *Pager
 	inputBox *InputBox
+	flags    search.Flags
+
+	// True if starting this filter put the previous one on p.filterStack. If
+	// so, cancelling brings the previous one back.
+	stackedPrevious bool
+
+	filterHistoryIndex int
+	userEditedText     string
+	userEditedFlags    search.Flags
 }
 
 func NewPagerModeFilter(p *Pager) *PagerModeFilter {
 	m := &PagerModeFilter{
-		pager: p,
+		pager:              p,
+		filterHistoryIndex: len(p.filterHistory.entries), // Past the end
 	}
 	m.inputBox = &InputBox{
 		accept: INPUTBOX_ACCEPT_ALL,
@@ -25,29 +38,82 @@ func NewPagerModeFilter(p *Pager) *PagerModeFilter {
 }
 
 func (m PagerModeFilter) drawFooter(_ string, _ string, _ string) {
-	m.inputBox.draw(m.pager.screen, "Type to filter, 'ENTER' submits, 'ESC' cancels", "Filter: ")
+	prompt := searchPrompt("Filter", m.flags)
+	if len(m.pager.filterStack) > 0 {
+		// "Filter: " -> "Filter #2: "
+		prompt = strings.TrimSuffix(prompt, ": ") + fmt.Sprintf(" #%d: ", len(m.pager.filterStack)+1)
+	}
+	if context := m.pager.filterContext.String(); context != "" {
+		// "Filter: " -> "Filter -C2: "
+		prompt = strings.TrimSuffix(prompt, ": ") + " " + context + ": "
+	}
+	m.inputBox.draw(m.pager.screen, "Type to filter, 'ENTER' submits, 'ESC' cancels, '↑↓' navigate history", prompt)
 }
 
 func (m *PagerModeFilter) updateFilterPattern(text string) {
-	m.pager.filter.For(text)
-	m.pager.search.For(text)
+	m.pager.filter.ForExpression(text, m.flags)
+	m.pager.search.ForExpression(text, m.flags)
+}
+
+func (m *PagerModeFilter) moveFilterHistoryIndex(delta int) {
+	history := m.pager.filterHistory
+	if len(history.entries) == 0 {
+		return
+	}
+
+	m.filterHistoryIndex += delta
+	if m.filterHistoryIndex < 0 {
+		m.filterHistoryIndex = 0
+	}
+	if m.filterHistoryIndex > len(history.entries) {
+		m.filterHistoryIndex = len(history.entries) // Beyond the end of the history
+	}
+
+	if m.filterHistoryIndex == len(history.entries) {
+		// Reset to whatever the user typed last
+		m.flags = m.userEditedFlags
+		m.inputBox.setText(m.userEditedText)
+	} else {
+		text, flags := parseSearchHistoryEntry(history.entries[m.filterHistoryIndex])
+		m.flags = flags
+		m.inputBox.setText(text)
+	}
 }
 
 func (m *PagerModeFilter) onKey(key twin.KeyCode) {
 	if m.inputBox.handleKey(key) {
+		m.filterHistoryIndex = len(m.pager.filterHistory.entries) // Reset history index when user types
+		m.userEditedText = m.inputBox.text
+		m.userEditedFlags = m.flags
 		return
 	}
 
 	switch key {
 	case twin.KeyEnter:
+		m.pager.filterHistory.addEntry(searchHistoryEntry(m.inputBox.text, m.flags))
 		m.pager.mode = PagerModeViewing{pager: m.pager}
+		if err := m.pager.filter.ExpressionError(); err != nil {
+			// Tell the user why their expression is being filtered for as-is
+			m.pager.mode = &PagerModeInfo{Pager: m.pager, Text: "Filtering for the text as-is: " + err.Error()}
+		}
 
 	case twin.KeyEscape:
+		m.pager.filterHistory.addEntry(searchHistoryEntry(m.inputBox.text, m.flags))
 		m.pager.mode = PagerModeViewing{pager: m.pager}
 		m.pager.filter = search.Search{}
-		m.pager.search.Clear()
+		if m.stackedPrevious && len(m.pager.filterStack) > 0 {
+			m.pager.popFilter()
+		} else {
+			m.pager.search.Clear()
+		}
+
+	case twin.KeyUp:
+		m.moveFilterHistoryIndex(-1)
 
-	case twin.KeyUp, twin.KeyDown, twin.KeyPgUp, twin.KeyPgDown:
+	case twin.KeyDown:
+		m.moveFilterHistoryIndex(1)
+
+	case twin.KeyPgUp, twin.KeyPgDown:
 		viewing := PagerModeViewing{pager: m.pager}
 		viewing.onKey(key)
 
@@ -57,5 +123,16 @@ func (m *PagerModeFilter) onKey(key twin.KeyCode) {
 }
 
 func (m *PagerModeFilter) onRune(char rune) {
-	m.inputBox.handleRune(char)
+	m.filterHistoryIndex = len(m.pager.filterHistory.entries) // Reset history index when user types
+
+	if toggleSearchFlag(&m.flags, char) {
+		m.updateFilterPattern(m.inputBox.text)
+	} else if char == '\x18' { // CTRL-x
+		m.pager.cycleFilterContext()
+	} else {
+		m.inputBox.handleRune(char)
+	}
+
+	m.userEditedText = m.inputBox.text
+	m.userEditedFlags = m.flags
 }
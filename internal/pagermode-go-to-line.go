@@ -38,12 +38,7 @@ func (m *PagerModeGotoLine) updateLineNumber(text string) {
 		log.Debugf("Got non-positive goto line number: %d", newLineNumber)
 		return
 	}
-	targetIndex := linemetadata.IndexFromOneBased(newLineNumber)
-	m.pager.scrollPosition = NewScrollPositionFromIndex(
-		targetIndex,
-		"onGotoLineKey",
-	)
-	m.pager.setTargetLine(&targetIndex)
+	m.pager.gotoLine(linemetadata.IndexFromOneBased(newLineNumber))
 }
 
 func (m *PagerModeGotoLine) onKey(key twin.KeyCode) {
@@ -74,9 +69,10 @@ func (m *PagerModeGotoLine) onRune(char rune) {
 		return
 	}
 
-	if char == 'g' {
-		p.scrollPosition = newScrollPosition("Pager scroll position")
-		p.handleScrolledUp()
+	if pressed, _ := p.keymap.actionForRune(char); pressed == actionGoToLine {
+		// Pressed twice, like 'gg' in vim
+		p.recordJump()
+		p.scrollToStart()
 		p.mode = PagerModeViewing{pager: p}
 		return
 	}
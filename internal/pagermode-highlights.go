@@ -0,0 +1,74 @@
+// List the highlights, and let the user remove them by number.
+
+package internal
+
+import (
+	"fmt"
+
+	"github.com/walles/moor/v2/twin"
+)
+
+type PagerModeHighlights struct {
+	pager *Pager
+}
+
+func (m PagerModeHighlights) drawFooter(_ string, _ string, _ string) {
+	p := m.pager
+
+	width, height := p.screen.Size()
+
+	pos := 0
+	for _, token := range "Remove highlight: " {
+		pos += p.screen.SetCell(pos, height-1, twin.NewStyledRune(token, statusbarStyle))
+	}
+
+	for i, highlight := range p.highlights {
+		for _, token := range fmt.Sprintf("%d", i+1) {
+			pos += p.screen.SetCell(pos, height-1, twin.NewStyledRune(token, statusbarStyle.WithAttr(twin.AttrBold)))
+		}
+		pos += p.screen.SetCell(pos, height-1, twin.NewStyledRune(' ', statusbarStyle))
+
+		for _, token := range highlight.Pattern.String() {
+			pos += p.screen.SetCell(pos, height-1, twin.NewStyledRune(token, highlight.Style))
+		}
+		for _, token := range "  " {
+			pos += p.screen.SetCell(pos, height-1, twin.NewStyledRune(token, statusbarStyle))
+		}
+	}
+
+	for _, cell := range renderHelpText("Press a number to remove, 'ESC' to exit") {
+		pos += p.screen.SetCell(pos, height-1, cell)
+	}
+
+	for pos < width {
+		pos += p.screen.SetCell(pos, height-1, twin.NewStyledRune(' ', statusbarStyle))
+	}
+}
+
+func (m PagerModeHighlights) onKey(key twin.KeyCode) {
+	p := m.pager
+
+	switch key {
+	case twin.KeyEnter, twin.KeyEscape:
+		p.mode = PagerModeViewing{pager: p}
+
+	default:
+		p.mode = PagerModeViewing{pager: p}
+		p.mode.onKey(key)
+	}
+}
+
+func (m PagerModeHighlights) onRune(char rune) {
+	p := m.pager
+
+	if char < '1' || char > '0'+maxHighlights {
+		p.mode = PagerModeViewing{pager: p}
+		p.mode.onRune(char)
+		return
+	}
+
+	p.removeHighlight(int(char - '1'))
+	if len(p.highlights) == 0 {
+		p.mode = &PagerModeInfo{Pager: p, Text: "All highlights removed"}
+	}
+}
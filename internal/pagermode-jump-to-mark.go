@@ -26,7 +26,7 @@ func (m PagerModeJumpToMark) drawFooter(_ string, _ string, _ string) {
 func (m PagerModeJumpToMark) getMarkPrompt() string {
 	// Special case having zero, one or multiple marks
 	if len(m.pager.bookmarks) == 0 {
-		return "No marks set, press 'm' to set one!"
+		return "No marks set, press " + m.pager.keymap.describeFirstKey(actionSetMark) + " to set one!"
 	}
 
 	if len(m.pager.bookmarks) == 1 {
@@ -73,6 +73,7 @@ func (m PagerModeJumpToMark) onRune(char rune) {
 
 	destination, ok := m.pager.bookmarks[char]
 	if ok {
+		m.pager.recordJump()
 		m.pager.scrollPosition = destination
 	}
 
@@ -37,6 +37,6 @@ func (m PagerModeMark) onKey(key twin.KeyCode) {
 }
 
 func (m PagerModeMark) onRune(char rune) {
-	m.pager.bookmarks[char] = m.pager.scrollPosition
+	m.pager.bookmarks[char] = m.pager.markPosition()
 	m.pager.mode = PagerModeViewing(m)
 }
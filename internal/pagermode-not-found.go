@@ -16,14 +16,12 @@ func (m PagerModeNotFound) onKey(key twin.KeyCode) {
 }
 
 func (m PagerModeNotFound) onRune(char rune) {
-	switch char {
-
-	// Should match the pagermode-viewing.go next-search-hit bindings
-	case 'n':
+	pressed, _ := m.pager.keymap.actionForRune(char)
+	switch pressed {
+	case actionSearchNext:
 		m.pager.scrollToNextSearchHit()
 
-	// Should match the pagermode-viewing.go previous-search-hit bindings
-	case 'p', 'N':
+	case actionSearchPrevious:
 		m.pager.scrollToPreviousSearchHit()
 
 	default:
@@ -25,7 +25,7 @@ func TestNotFoundFindPrevious(t *testing.T) {
 
 	// We should now be on the second line saying "bepa"
 	assert.Equal(t, pager.scrollPosition.lineIndex(pager).Index(), 1)
-	assert.Assert(t, pager.isViewing())
+	assert.Equal(t, "Search wrapped around to the bottom", pager.mode.(*PagerModeInfo).Text)
 }
 
 func TestWrapSearchBackwards(t *testing.T) {
@@ -46,5 +46,5 @@ func TestWrapSearchBackwards(t *testing.T) {
 	// showing two lines on the screen, this puts the pager line number at 3
 	// (not 4).
 	assert.Equal(t, pager.scrollPosition.lineIndex(pager).Index(), 2)
-	assert.Assert(t, pager.isViewing())
+	assert.Equal(t, "Search wrapped around to the bottom", pager.mode.(*PagerModeInfo).Text)
 }
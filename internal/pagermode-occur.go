@@ -0,0 +1,246 @@
+// Occur view: list all lines matching the search at the bottom of the screen,
+// and show the selected one in context above.
+
+package internal
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/search"
+	"github.com/walles/moor/v2/twin"
+)
+
+type PagerModeOccur struct {
+	pager *Pager
+
+	// Lines matching the search. Filter points to search below.
+	hits   *FilteringReader
+	search search.Search
+
+	// Index into the hits
+	selected int
+
+	// The first hit shown in the list
+	firstListed int
+
+	// Pager position before the occur view started
+	initialScrollPosition scrollPosition
+}
+
+// Enter the occur view for the current search, or explain why not
+func (p *Pager) startOccur() {
+	if p.search.Inactive() {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Search for something first, then press " + p.keymap.describeFirstKey(actionListHits) + " to list all hits"}
+		return
+	}
+
+	if p.isShowingHelp {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Listing all hits doesn't work in the help text"}
+		return
+	}
+
+	if p.isFiltering() {
+		// Hits are mapped back to document lines using their line numbers,
+		// which only works on unfiltered documents
+		p.mode = &PagerModeInfo{Pager: p, Text: "Listing all hits doesn't work while filtering, press " + p.keymap.describeFirstKey(actionPopFilter) + " to remove filters"}
+		return
+	}
+
+	if p.diffSideIndex() >= 0 {
+		// Same problem, the aligned view has filler lines
+		p.mode = &PagerModeInfo{Pager: p, Text: "Listing all hits doesn't work while comparing files"}
+		return
+	}
+
+	p.readerLock.Lock()
+	r := p.readers[p.currentReader]
+	p.readerLock.Unlock()
+
+	m := &PagerModeOccur{
+		pager:                 p,
+		search:                p.search,
+		initialScrollPosition: p.scrollPosition,
+	}
+	m.hits = &FilteringReader{
+		BackingReader: r,
+		Filter:        &m.search,
+	}
+
+	if m.hits.GetLineCount() == 0 {
+		p.mode = &PagerModeInfo{Pager: p, Text: "No hits: " + p.search.String()}
+		return
+	}
+
+	// Start at the first hit at or below the top of the screen
+	if topIndex := p.lineIndex(); topIndex != nil {
+		for i, line := range m.hits.getAllLines() {
+			if line.Number.AsZeroBased() >= topIndex.Index() {
+				m.selected = i
+				break
+			}
+		}
+	}
+
+	p.mode = m
+	m.scrollToSelected()
+}
+
+// How many hits are listed at the bottom of the screen
+func (m *PagerModeOccur) listHeight() linemetadata.ScreenLines {
+	_, height := m.pager.ScreenSize()
+
+	// Leave at least two thirds of the screen for the document
+	listHeight := (height - 1) / 3
+	if listHeight < 1 {
+		listHeight = 1
+	}
+
+	return listHeight
+}
+
+// Scroll the document so that the selected hit is in the middle of it
+func (m *PagerModeOccur) scrollToSelected() {
+	p := m.pager
+
+	hit := m.hits.GetLine(linemetadata.IndexFromZeroBased(m.selected))
+	if hit == nil {
+		return
+	}
+
+	hitIndex := linemetadata.IndexFromZeroBased(hit.Number.AsZeroBased())
+	p.scrollPosition = NewScrollPositionFromIndex(hitIndex, "Occur").PreviousLine(p.visibleHeight() / 2)
+	p.leftColumnZeroBased = 0
+}
+
+func (m *PagerModeOccur) moveSelection(delta int) {
+	m.selected += delta
+	m.clampSelection()
+	m.scrollToSelected()
+}
+
+// Keep the selection within the hits, and the list scrolled so that the
+// selection is visible
+func (m *PagerModeOccur) clampSelection() {
+	hitCount := m.hits.GetLineCount()
+	if m.selected >= hitCount {
+		m.selected = hitCount - 1
+	}
+	if m.selected < 0 {
+		m.selected = 0
+	}
+
+	listHeight := int(m.listHeight())
+	if m.selected < m.firstListed {
+		m.firstListed = m.selected
+	}
+	if m.selected >= m.firstListed+listHeight {
+		m.firstListed = m.selected - listHeight + 1
+	}
+}
+
+func (m *PagerModeOccur) drawFooter(_ string, _ string, _ string) {
+	p := m.pager
+
+	width, height := p.screen.Size()
+	listHeight := int(m.listHeight())
+	firstRow := height - 1 - listHeight
+
+	// Keep the selection visible even if the screen was resized
+	m.clampSelection()
+
+	listed := m.hits.GetLines(linemetadata.IndexFromZeroBased(m.firstListed), listHeight).Lines
+
+	numberWidth := 0
+	if len(listed) > 0 {
+		// The last listed line has the widest line number
+		numberWidth = len(fmt.Sprint(listed[len(listed)-1].Number.AsOneBased()))
+	}
+
+	for row := 0; row < listHeight; row++ {
+		screenRow := firstRow + row
+		for column := 0; column < width; column++ {
+			p.screen.SetCell(column, screenRow, twin.NewStyledRune(' ', twin.StyleDefault))
+		}
+
+		if row >= len(listed) {
+			continue
+		}
+		line := listed[row]
+
+		numberStyle := lineNumbersStyle
+		if line.Index.Index() == m.selected {
+			numberStyle = statusbarStyle
+		}
+
+		column := 0
+		for _, token := range fmt.Sprintf("%*d ", numberWidth, line.Number.AsOneBased()) {
+			column += p.screen.SetCell(column, screenRow, twin.NewStyledRune(token, numberStyle))
+		}
+
+		highlighted := line.HighlightedTokens(plainTextStyle, searchHitStyle, m.search, p.highlights, width-column)
+		for _, cell := range highlighted.StyledRunes {
+			if column >= width {
+				break
+			}
+			column += p.screen.SetCell(column, screenRow, cell.ToStyledRune())
+		}
+	}
+
+	status := fmt.Sprintf("%d/%d", m.selected+1, m.hits.GetLineCount())
+	p.setFooter("", "Hits for "+m.search.String(), ": "+status, "'↑↓' select, 'ENTER' view hit, 'ESC' go back")
+}
+
+func (m *PagerModeOccur) onKey(key twin.KeyCode) {
+	p := m.pager
+
+	switch key {
+	case twin.KeyUp:
+		m.moveSelection(-1)
+
+	case twin.KeyDown:
+		m.moveSelection(1)
+
+	case twin.KeyPgUp:
+		m.moveSelection(-int(m.listHeight()))
+
+	case twin.KeyPgDown:
+		m.moveSelection(int(m.listHeight()))
+
+	case twin.KeyHome:
+		m.moveSelection(-m.selected)
+
+	case twin.KeyEnd:
+		m.moveSelection(m.hits.GetLineCount())
+
+	case twin.KeyEnter:
+		p.mode = PagerModeViewing{pager: p}
+
+	case twin.KeyEscape:
+		p.mode = PagerModeViewing{pager: p}
+		p.scrollPosition = m.initialScrollPosition
+
+	default:
+		log.Debugf("Unhandled occur key event %v", key)
+	}
+}
+
+func (m *PagerModeOccur) onRune(char rune) {
+	p := m.pager
+
+	switch char {
+	case 'k', 'p':
+		m.moveSelection(-1)
+
+	case 'j', 'n':
+		m.moveSelection(1)
+
+	case 'q':
+		p.mode = PagerModeViewing{pager: p}
+		p.scrollPosition = m.initialScrollPosition
+
+	default:
+		log.Debugf("Unhandled occur rune %q", char)
+	}
+}
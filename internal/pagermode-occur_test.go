@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestOccur(t *testing.T) {
+	reader := reader.NewFromTextForTesting("TestOccur", "a\nhit 1\nb\nc\nd\ne\nf\ng\nh\nhit 2\ni\nj")
+	assert.NilError(t, reader.Wait())
+
+	pager := NewPager(reader)
+	screen := twin.NewFakeScreen(20, 10)
+	pager.screen = screen
+	pager.mode = PagerModeViewing{pager: pager}
+	pager.ShowLineNumbers = false
+	pager.showLineNumbers = false
+	pager.search.For("hit")
+
+	pager.startOccur()
+	occur := pager.mode.(*PagerModeOccur)
+
+	// Ten lines screen, one status line, three lines for the list, which leaves
+	// six for the document
+	assert.Equal(t, int(pager.visibleHeight()), 6)
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(6)), " 2 hit 1")
+	assert.Equal(t, rowToString(screen.GetRow(7)), "10 hit 2")
+	assert.Equal(t, rowToString(screen.GetRow(8)), "")
+
+	// Select the second hit, it should be scrolled into the document view
+	occur.onKey(twin.KeyDown)
+	pager.redraw("")
+	documentRows := []string{}
+	for row := 0; row < 6; row++ {
+		documentRows = append(documentRows, rowToString(screen.GetRow(row)))
+	}
+	assert.Assert(t, slices.Contains(documentRows, "hit 2"), "Document: %v", documentRows)
+
+	// Going back should restore the original position
+	occur.onKey(twin.KeyEscape)
+	assert.Equal(t, "Viewing", modeName(pager))
+	assert.Assert(t, pager.lineIndex().IsZero())
+}
+
+func TestOccurWithoutSearch(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("TestOccurWithoutSearch", "a"))
+	pager.screen = twin.NewFakeScreen(20, 10)
+
+	pager.startOccur()
+	assert.Equal(t, "Info", modeName(pager))
+}
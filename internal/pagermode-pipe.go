@@ -0,0 +1,198 @@
+// Pipe lines into a shell command. First ask what to pipe, then for the
+// command.
+
+package internal
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"golang.org/x/exp/maps"
+)
+
+type PagerModePipeRange struct {
+	pager *Pager
+}
+
+type PagerModePipeMark struct {
+	pager *Pager
+}
+
+type PagerModePipeCommand struct {
+	pager    *Pager
+	inputBox InputBox
+	lines    []reader.NumberedLine
+}
+
+// Enter pipe mode, or explain why not
+func (p *Pager) startPiping() {
+	if os.Getenv("LESSSECURE") == "1" {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Not piping since LESSSECURE=1 is set in the environment"}
+		return
+	}
+
+	if p.isShowingHelp {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Piping the help text is not supported"}
+		return
+	}
+
+	p.mode = PagerModePipeRange{pager: p}
+}
+
+func (p *Pager) askForPipeCommand(lines []reader.NumberedLine) {
+	if len(lines) == 0 {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Nothing to pipe"}
+		return
+	}
+
+	p.mode = &PagerModePipeCommand{
+		pager: p,
+		inputBox: InputBox{
+			accept: INPUTBOX_ACCEPT_ALL,
+		},
+		lines: lines,
+	}
+}
+
+func (m PagerModePipeRange) drawFooter(_ string, _ string, _ string) {
+	if m.pager.isFiltering() {
+		m.pager.drawQuestion("Pipe [a]ll lines, [v]isible lines, from [h]ere to the end, from a [m]ark to here, or [f]iltered lines: ")
+	} else {
+		m.pager.drawQuestion("Pipe [a]ll lines, [v]isible lines, from [h]ere to the end, or from a [m]ark to here: ")
+	}
+}
+
+func (m PagerModePipeRange) onKey(key twin.KeyCode) {
+	if key == twin.KeyEscape {
+		m.pager.mode = PagerModeViewing{pager: m.pager}
+		return
+	}
+
+	log.Debugf("Unhandled pipe range key event %v", key)
+}
+
+func (m PagerModePipeRange) onRune(char rune) {
+	p := m.pager
+
+	switch char {
+	case 'a':
+		if !p.requireReadingDone("piping") {
+			return
+		}
+		p.askForPipeCommand(p.linesToPipe(pipeRangeAll, 0))
+
+	case 'v':
+		p.askForPipeCommand(p.linesToPipe(pipeRangeVisible, 0))
+
+	case 'h':
+		if !p.requireReadingDone("piping") {
+			return
+		}
+		p.askForPipeCommand(p.linesToPipe(pipeRangeFromHere, 0))
+
+	case 'm':
+		if len(p.bookmarks) == 0 {
+			p.mode = &PagerModeInfo{Pager: p, Text: "No marks set, press " + p.keymap.describeFirstKey(actionSetMark) + " to set one"}
+			return
+		}
+		p.mode = PagerModePipeMark(m)
+
+	case 'f':
+		if !p.isFiltering() {
+			log.Debugf("Not filtering, ignoring %q", char)
+			return
+		}
+		if !p.requireReadingDone("piping") {
+			return
+		}
+		p.askForPipeCommand(p.linesToPipe(pipeRangeFiltered, 0))
+
+	case 'q', '\x03':
+		p.mode = PagerModeViewing{pager: p}
+
+	default:
+		log.Debugf("Unhandled pipe range rune %q", char)
+	}
+}
+
+func (m PagerModePipeMark) drawFooter(_ string, _ string, _ string) {
+	marks := maps.Keys(m.pager.bookmarks)
+	slices.Sort(marks)
+
+	markStrings := []string{}
+	for _, mark := range marks {
+		markStrings = append(markStrings, string(mark))
+	}
+
+	m.pager.drawQuestion("Pipe from one of these marks to here: " + strings.Join(markStrings, ", "))
+}
+
+func (m PagerModePipeMark) onKey(_ twin.KeyCode) {
+	m.pager.mode = PagerModeViewing{pager: m.pager}
+}
+
+func (m PagerModePipeMark) onRune(char rune) {
+	p := m.pager
+
+	if _, ok := p.bookmarks[char]; !ok {
+		p.mode = &PagerModeInfo{Pager: p, Text: fmt.Sprintf("No such mark: %q", char)}
+		return
+	}
+
+	p.askForPipeCommand(p.linesToPipe(pipeRangeMark, char))
+}
+
+func (m *PagerModePipeCommand) drawFooter(_ string, _ string, _ string) {
+	prompt := fmt.Sprintf("Pipe %d lines to: ", len(m.lines))
+	if len(m.lines) == 1 {
+		prompt = "Pipe 1 line to: "
+	}
+
+	m.inputBox.draw(m.pager.screen, "'ENTER' runs, 'CTRL-o' opens the output in moor, 'ESC' cancels", prompt)
+}
+
+func (m *PagerModePipeCommand) onKey(key twin.KeyCode) {
+	p := m.pager
+
+	if m.inputBox.handleKey(key) {
+		return
+	}
+
+	switch key {
+	case twin.KeyEnter:
+		if strings.TrimSpace(m.inputBox.text) == "" {
+			p.mode = PagerModeViewing{pager: p}
+			return
+		}
+		p.pipe(m.lines, m.inputBox.text, false)
+
+	case twin.KeyEscape:
+		p.mode = PagerModeViewing{pager: p}
+
+	default:
+		log.Debugf("Unhandled pipe command key event %v", key)
+	}
+}
+
+func (m *PagerModePipeCommand) onRune(char rune) {
+	p := m.pager
+
+	switch char {
+	case '\x03': // CTRL-c
+		p.mode = PagerModeViewing{pager: p}
+
+	case '\x0f': // CTRL-o
+		if strings.TrimSpace(m.inputBox.text) == "" {
+			return
+		}
+		p.pipe(m.lines, m.inputBox.text, true)
+
+	default:
+		m.inputBox.handleRune(char)
+	}
+}
@@ -0,0 +1,157 @@
+// Save the current buffer or view to a file. First ask for a path, then for
+// the format, and finally for permission to overwrite if the file exists.
+
+package internal
+
+import (
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/twin"
+)
+
+type PagerModeSave struct {
+	pager    *Pager
+	inputBox InputBox
+}
+
+type PagerModeSaveFormat struct {
+	pager *Pager
+	path  string
+}
+
+type PagerModeSaveOverwrite struct {
+	pager  *Pager
+	path   string
+	format saveFormat
+}
+
+// Enter save mode, or explain why not
+func (p *Pager) startSaving() {
+	if os.Getenv("LESSSECURE") == "1" {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Not saving since LESSSECURE=1 is set in the environment"}
+		return
+	}
+
+	if p.isShowingHelp {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Saving the help text is not supported"}
+		return
+	}
+
+	p.mode = &PagerModeSave{
+		pager: p,
+		inputBox: InputBox{
+			accept: INPUTBOX_ACCEPT_ALL,
+		},
+	}
+}
+
+// Draw a question at the bottom of the screen, followed by a cursor
+func (p *Pager) drawQuestion(question string) {
+	_, screenHeight := p.ScreenSize()
+	height := int(screenHeight)
+
+	pos := 0
+	for _, token := range question {
+		pos += p.screen.SetCell(pos, height-1, twin.NewStyledRune(token, twin.StyleDefault))
+	}
+
+	// Add a cursor
+	p.screen.SetCell(pos, height-1, twin.NewStyledRune(' ', twin.StyleDefault.WithAttr(twin.AttrReverse)))
+}
+
+func (m *PagerModeSave) drawFooter(_ string, _ string, _ string) {
+	m.inputBox.draw(m.pager.screen, "'ENTER' submits, 'ESC' cancels", "Save to: ")
+}
+
+func (m *PagerModeSave) onKey(key twin.KeyCode) {
+	p := m.pager
+
+	if m.inputBox.handleKey(key) {
+		return
+	}
+
+	switch key {
+	case twin.KeyEnter:
+		if m.inputBox.text == "" {
+			p.mode = PagerModeViewing{pager: p}
+			return
+		}
+		p.mode = &PagerModeSaveFormat{pager: p, path: m.inputBox.text}
+
+	case twin.KeyEscape:
+		p.mode = PagerModeViewing{pager: p}
+
+	default:
+		log.Debugf("Unhandled save key event %v", key)
+	}
+}
+
+func (m *PagerModeSave) onRune(char rune) {
+	if char == '\x03' {
+		// CTRL-c
+		m.pager.mode = PagerModeViewing{pager: m.pager}
+		return
+	}
+
+	m.inputBox.handleRune(char)
+}
+
+func (m *PagerModeSaveFormat) drawFooter(_ string, _ string, _ string) {
+	if m.pager.isFiltering() {
+		m.pager.drawQuestion("Save [r]aw buffer, [f]iltered lines or filtered [p]lain text: ")
+	} else {
+		m.pager.drawQuestion("Save [r]aw buffer or [p]lain text: ")
+	}
+}
+
+func (m *PagerModeSaveFormat) onKey(key twin.KeyCode) {
+	if key == twin.KeyEscape {
+		m.pager.mode = PagerModeViewing{pager: m.pager}
+		return
+	}
+
+	log.Debugf("Unhandled save format key event %v", key)
+}
+
+func (m *PagerModeSaveFormat) onRune(char rune) {
+	p := m.pager
+
+	switch char {
+	case 'r':
+		p.save(m.path, saveFormatRaw, false)
+
+	case 'f':
+		if !p.isFiltering() {
+			log.Debugf("Not filtering, ignoring %q", char)
+			return
+		}
+		p.save(m.path, saveFormatFiltered, false)
+
+	case 'p':
+		p.save(m.path, saveFormatPlain, false)
+
+	case 'q', '\x03':
+		p.mode = PagerModeViewing{pager: p}
+
+	default:
+		log.Debugf("Unhandled save format rune %q", char)
+	}
+}
+
+func (m *PagerModeSaveOverwrite) drawFooter(_ string, _ string, _ string) {
+	m.pager.drawQuestion(m.path + " already exists, overwrite it? [y/n]: ")
+}
+
+func (m *PagerModeSaveOverwrite) onKey(_ twin.KeyCode) {
+	m.pager.mode = &PagerModeInfo{Pager: m.pager, Text: "Not saved"}
+}
+
+func (m *PagerModeSaveOverwrite) onRune(char rune) {
+	if char == 'y' {
+		m.pager.save(m.path, m.format, true)
+		return
+	}
+
+	m.pager.mode = &PagerModeInfo{Pager: m.pager, Text: "Not saved"}
+}
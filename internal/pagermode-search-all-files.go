@@ -0,0 +1,254 @@
+// List which files have search hits, and let the user jump into one of them.
+
+package internal
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/twin"
+)
+
+type fileSearchHits struct {
+	readerIndex int
+	name        string
+	hits        []linemetadata.Index
+
+	// The file wasn't done reading when it was searched, so there could be
+	// more hits
+	partial bool
+}
+
+type PagerModeSearchAllFiles struct {
+	pager *Pager
+
+	// The start of a file number with more than one digit
+	typed string
+
+	// Gets a value when all files have been searched
+	searched chan bool
+
+	// Protects the fields below, which are updated while searching in the
+	// background
+	lock sync.Mutex
+
+	// Only files with hits are listed here
+	files []fileSearchHits
+
+	// True until all files have been searched
+	searching bool
+}
+
+// Search all files in the background, and list the ones with hits as they are
+// found. If there is no search, tell the user so instead.
+func (p *Pager) searchAllFiles() {
+	if p.search.Inactive() {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Search for something first, then type ':s' to search all files"}
+		return
+	}
+
+	p.readerLock.Lock()
+	readers := p.readers
+	p.readerLock.Unlock()
+
+	mode := &PagerModeSearchAllFiles{
+		pager:     p,
+		searched:  make(chan bool, 1),
+		searching: true,
+	}
+	p.mode = mode
+
+	search := p.search
+	events := p.screen.Events()
+	redraw := func() {
+		select {
+		case events <- eventSearchHitsCounted{}:
+			// Event delivered
+		default:
+			// Queue full, we'll get redrawn for some other reason soon enough
+		}
+	}
+
+	go func() {
+		defer func() {
+			PanicHandler("searchAllFiles()/goroutine", recover(), debug.Stack())
+		}()
+
+		for i, r := range readers {
+			// Before counting the lines, in case reading finishes after that
+			partial := !r.ReadingDone.Load()
+
+			// Other files are never filtered, so we search them directly
+			hits := FindAllHits(r, search, linemetadata.Index{}, linemetadata.IndexFromZeroBased(r.GetLineCount()))
+			if len(hits) == 0 {
+				continue
+			}
+
+			name := fmt.Sprintf("file %d", i+1)
+			if r.DisplayName != nil {
+				name = *r.DisplayName
+			}
+
+			mode.lock.Lock()
+			mode.files = append(mode.files, fileSearchHits{readerIndex: i, name: name, hits: hits, partial: partial})
+			mode.lock.Unlock()
+			redraw()
+		}
+
+		mode.lock.Lock()
+		mode.searching = false
+		mode.lock.Unlock()
+		redraw()
+
+		select {
+		case mode.searched <- true:
+		default:
+		}
+	}()
+}
+
+func (m *PagerModeSearchAllFiles) drawFooter(_ string, _ string, _ string) {
+	p := m.pager
+
+	width, height := p.screen.Size()
+
+	m.lock.Lock()
+	files := m.files
+	searching := m.searching
+	m.lock.Unlock()
+
+	pos := 0
+	if len(files) == 0 && !searching {
+		for _, token := range "Not found in any file: " + p.search.String() + "  " {
+			pos += p.screen.SetCell(pos, height-1, twin.NewStyledRune(token, statusbarStyle))
+		}
+	} else {
+		for _, token := range "Hits: " {
+			pos += p.screen.SetCell(pos, height-1, twin.NewStyledRune(token, statusbarStyle))
+		}
+	}
+
+	for _, file := range files {
+		for _, token := range fmt.Sprintf("%d", file.readerIndex+1) {
+			pos += p.screen.SetCell(pos, height-1, twin.NewStyledRune(token, statusbarStyle.WithAttr(twin.AttrBold)))
+		}
+
+		count := strconv.Itoa(len(file.hits))
+		if file.partial {
+			count += ", reading..."
+		}
+		for _, token := range fmt.Sprintf(" %s (%s)  ", file.name, count) {
+			pos += p.screen.SetCell(pos, height-1, twin.NewStyledRune(token, statusbarStyle))
+		}
+	}
+
+	if searching {
+		for _, token := range "Searching...  " {
+			pos += p.screen.SetCell(pos, height-1, twin.NewStyledRune(token, statusbarStyle))
+		}
+	}
+
+	helpText := "Type a file number to go there, 'ESC' to exit"
+	if len(files) == 0 {
+		helpText = "'ESC' to exit"
+	}
+	if m.typed != "" {
+		helpText = "File " + m.typed + ", 'ENTER' to go there, 'ESC' to exit"
+	}
+	for _, cell := range renderHelpText(helpText) {
+		pos += p.screen.SetCell(pos, height-1, cell)
+	}
+
+	for pos < width {
+		pos += p.screen.SetCell(pos, height-1, twin.NewStyledRune(' ', statusbarStyle))
+	}
+}
+
+func (m *PagerModeSearchAllFiles) onKey(key twin.KeyCode) {
+	p := m.pager
+
+	switch key {
+	case twin.KeyEnter:
+		if m.typed != "" {
+			m.goToTypedFile()
+			return
+		}
+		p.mode = PagerModeViewing{pager: p}
+
+	case twin.KeyEscape:
+		p.mode = PagerModeViewing{pager: p}
+
+	default:
+		p.mode = PagerModeViewing{pager: p}
+		p.mode.onKey(key)
+	}
+}
+
+func (m *PagerModeSearchAllFiles) onRune(char rune) {
+	p := m.pager
+
+	if char < '0' || char > '9' {
+		p.mode = PagerModeViewing{pager: p}
+		p.mode.onRune(char)
+		return
+	}
+
+	m.typed += string(char)
+
+	m.lock.Lock()
+	files := m.files
+	searching := m.searching
+	m.lock.Unlock()
+
+	// Go there right away unless more digits could make another file number
+	if searching {
+		// Files found later could have longer numbers
+		p.readerLock.Lock()
+		readerCount := len(p.readers)
+		p.readerLock.Unlock()
+
+		for i := range readerCount {
+			number := strconv.Itoa(i + 1)
+			if number != m.typed && strings.HasPrefix(number, m.typed) {
+				return
+			}
+		}
+	}
+	for _, file := range files {
+		number := strconv.Itoa(file.readerIndex + 1)
+		if number != m.typed && strings.HasPrefix(number, m.typed) {
+			return
+		}
+	}
+
+	m.goToTypedFile()
+}
+
+func (m *PagerModeSearchAllFiles) goToTypedFile() {
+	p := m.pager
+
+	m.lock.Lock()
+	files := m.files
+	searching := m.searching
+	m.lock.Unlock()
+
+	for _, file := range files {
+		if m.typed != strconv.Itoa(file.readerIndex+1) {
+			continue
+		}
+
+		p.mode = PagerModeViewing{pager: p}
+		p.switchToFileAtLine(file.readerIndex, file.hits[0])
+		return
+	}
+
+	if searching {
+		p.mode = &PagerModeInfo{Pager: p, Text: "No hits in file " + m.typed + " so far, still searching"}
+		return
+	}
+	p.mode = &PagerModeInfo{Pager: p, Text: "No hits in file " + m.typed}
+}
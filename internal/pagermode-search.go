@@ -2,6 +2,7 @@ package internal
 
 import (
 	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/search"
 	"github.com/walles/moor/v2/twin"
 )
 
@@ -19,6 +20,38 @@ type PagerModeSearch struct {
 	inputBox              *InputBox
 	searchHistoryIndex    int
 	userEditedText        string
+	userEditedFlags       search.Flags
+	flags                 search.Flags
+}
+
+// Toggle a search flag if char is one of the flag toggling control characters.
+// Returns true if a flag was toggled.
+func toggleSearchFlag(flags *search.Flags, char rune) bool {
+	switch char {
+	case '\x12': // CTRL-r
+		flags.Regexp = !flags.Regexp
+		flags.Literal = false
+	case '\x0e': // CTRL-n
+		flags.Literal = !flags.Literal
+		flags.Regexp = false
+	case '\x13': // CTRL-s
+		flags.CaseSensitive = !flags.CaseSensitive
+	case '\x17': // CTRL-w
+		flags.WholeWord = !flags.WholeWord
+	default:
+		return false
+	}
+
+	return true
+}
+
+// Example return value: "Search [regexp, word]: "
+func searchPrompt(prompt string, flags search.Flags) string {
+	if flags == (search.Flags{}) {
+		return prompt + ": "
+	}
+
+	return prompt + " [" + flags.String() + "]: "
 }
 
 func NewPagerModeSearch(p *Pager, direction SearchDirection, initialScrollPosition scrollPosition) *PagerModeSearch {
@@ -31,25 +64,29 @@ func NewPagerModeSearch(p *Pager, direction SearchDirection, initialScrollPositi
 	m.inputBox = &InputBox{
 		accept: INPUTBOX_ACCEPT_ALL,
 		onTextChanged: func(text string) {
-			m.pager.search.For(text)
-
-			switch m.direction {
-			case SearchDirectionBackward:
-				m.pager.scrollToSearchHitsBackwards()
-			case SearchDirectionForward:
-				m.pager.scrollToSearchHits()
-			}
+			m.updateSearch(text)
 		},
 	}
 	return m
 }
 
+func (m *PagerModeSearch) updateSearch(text string) {
+	m.pager.search.ForWithFlags(text, m.flags)
+
+	switch m.direction {
+	case SearchDirectionBackward:
+		m.pager.scrollToSearchHitsBackwards()
+	case SearchDirectionForward:
+		m.pager.scrollToSearchHits()
+	}
+}
+
 func (m PagerModeSearch) drawFooter(_ string, _ string, _ string) {
-	prompt := "Search: "
+	prompt := "Search"
 	if m.direction == SearchDirectionBackward {
-		prompt = "Search backwards: "
+		prompt = "Search backwards"
 	}
-	m.inputBox.draw(m.pager.screen, "Type to search, 'ENTER' submits, 'ESC' cancels, '↑↓' navigate history", prompt)
+	m.inputBox.draw(m.pager.screen, "Type to search, 'ENTER' submits, 'ESC' cancels, '↑↓' navigate history", searchPrompt(prompt, m.flags))
 }
 
 func (m *PagerModeSearch) moveSearchHistoryIndex(delta int) {
@@ -67,16 +104,19 @@ func (m *PagerModeSearch) moveSearchHistoryIndex(delta int) {
 
 	if m.searchHistoryIndex == len(m.pager.searchHistory.entries) {
 		// Reset to whatever the user typed last
+		m.flags = m.userEditedFlags
 		m.inputBox.setText(m.userEditedText)
 	} else {
 		// Get the history entry
-		m.inputBox.setText(m.pager.searchHistory.entries[m.searchHistoryIndex])
+		text, flags := parseSearchHistoryEntry(m.pager.searchHistory.entries[m.searchHistoryIndex])
+		m.flags = flags
+		m.inputBox.setText(text)
 	}
 }
 
 // Exit search mode, skip back to where we started
 func (m *PagerModeSearch) abort() {
-	m.pager.searchHistory.addEntry(m.inputBox.text)
+	m.pager.searchHistory.addEntry(searchHistoryEntry(m.inputBox.text, m.flags))
 	m.pager.mode = PagerModeViewing{pager: m.pager}
 	m.pager.scrollPosition = m.initialScrollPosition
 	m.pager.setTargetLine(nil) // Viewing doesn't need all lines
@@ -86,12 +126,14 @@ func (m *PagerModeSearch) onKey(key twin.KeyCode) {
 	if m.inputBox.handleKey(key) {
 		m.searchHistoryIndex = len(m.pager.searchHistory.entries) // Reset history index when user types
 		m.userEditedText = m.inputBox.text
+		m.userEditedFlags = m.flags
 		return
 	}
 
 	switch key {
 	case twin.KeyEnter:
-		m.pager.searchHistory.addEntry(m.inputBox.text)
+		m.pager.searchHistory.addEntry(searchHistoryEntry(m.inputBox.text, m.flags))
+		m.pager.recordJumpFrom(m.initialScrollPosition)
 		m.pager.mode = PagerModeViewing{pager: m.pager}
 		m.pager.setTargetLine(nil) // Viewing doesn't need all lines
 
@@ -99,7 +141,7 @@ func (m *PagerModeSearch) onKey(key twin.KeyCode) {
 		m.abort()
 
 	case twin.KeyPgUp, twin.KeyPgDown:
-		m.pager.searchHistory.addEntry(m.inputBox.text)
+		m.pager.searchHistory.addEntry(searchHistoryEntry(m.inputBox.text, m.flags))
 		m.pager.mode = PagerModeViewing{pager: m.pager}
 		m.pager.mode.onKey(key)
 		m.pager.setTargetLine(nil) // Viewing doesn't need all lines
@@ -123,6 +165,11 @@ func (m *PagerModeSearch) onRune(char rune) {
 	}
 
 	m.searchHistoryIndex = len(m.pager.searchHistory.entries) // Reset history index when user types
-	m.inputBox.handleRune(char)
+	if toggleSearchFlag(&m.flags, char) {
+		m.updateSearch(m.inputBox.text)
+	} else {
+		m.inputBox.handleRune(char)
+	}
 	m.userEditedText = m.inputBox.text
+	m.userEditedFlags = m.flags
 }
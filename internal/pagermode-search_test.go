@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/internal/search"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestSearchHistoryEntryRoundtrip(t *testing.T) {
+	for _, flags := range []search.Flags{
+		{},
+		{Regexp: true},
+		{Literal: true, CaseSensitive: true},
+		{WholeWord: true},
+	} {
+		text, parsedFlags := parseSearchHistoryEntry(searchHistoryEntry("a(b", flags))
+		assert.Equal(t, text, "a(b")
+		assert.Equal(t, parsedFlags, flags)
+	}
+
+	// No flags should be stored as-is for compatibility with less
+	assert.Equal(t, searchHistoryEntry("hello", search.Flags{}), "hello")
+
+	// Things that just look like our prefix should be left alone
+	text, flags := parseSearchHistoryEntry("(?moor:xyz)hello")
+	assert.Equal(t, text, "(?moor:xyz)hello")
+	assert.Equal(t, flags, search.Flags{})
+}
+
+func TestSearchPrompt(t *testing.T) {
+	assert.Equal(t, searchPrompt("Search", search.Flags{}), "Search: ")
+	assert.Equal(t, searchPrompt("Search", search.Flags{Regexp: true, WholeWord: true}), "Search [regexp, word]: ")
+}
+
+func TestSearchModeToggleFlags(t *testing.T) {
+	pager := NewPager(reader.NewFromTextForTesting("TestSearchModeToggleFlags", "concatenate\ncat"))
+	pager.searchHistory = &SearchHistory{}
+	pager.screen = twin.NewFakeScreen(20, 5)
+
+	searchMode := NewPagerModeSearch(pager, SearchDirectionForward, pager.scrollPosition)
+	pager.mode = searchMode
+
+	for _, char := range "cat" {
+		searchMode.onRune(char)
+	}
+	assert.Assert(t, pager.search.Matches("concatenate"))
+
+	searchMode.onRune('\x17') // CTRL-w, whole words only
+	assert.Assert(t, !pager.search.Matches("concatenate"))
+	assert.Assert(t, pager.search.Matches("cat"))
+	assert.Equal(t, searchMode.inputBox.text, "cat", "Toggling flags should not change the text")
+
+	searchMode.onRune('\x12') // CTRL-r, regexp
+	searchMode.onRune('\x0e') // CTRL-n, literal, should turn off regexp
+	assert.Equal(t, pager.search.Flags(), search.Flags{Literal: true, WholeWord: true})
+}
@@ -0,0 +1,292 @@
+// Select lines with the keyboard or by dragging with the mouse, and copy them
+// to the clipboard.
+
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/twin"
+)
+
+type PagerModeSelect struct {
+	pager *Pager
+
+	// Where selecting started. Both this and end are indices into
+	// p.Reader(), and either one can be the first of the selected lines.
+	anchor linemetadata.Index
+
+	// Moved by the keyboard or the mouse
+	end linemetadata.Index
+}
+
+// Start selecting at the cursor line, or at the top line of the screen
+func (p *Pager) startSelecting() {
+	if p.isShowingHelp {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Selecting in the help text is not supported"}
+		return
+	}
+
+	current := p.currentLineIndex()
+	if current == nil {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Nothing to select"}
+		return
+	}
+
+	p.mode = &PagerModeSelect{pager: p, anchor: *current, end: *current}
+}
+
+// The first and the last selected lines
+func (m *PagerModeSelect) selectedRange() (linemetadata.Index, linemetadata.Index) {
+	if m.end.IsBefore(m.anchor) {
+		return m.end, m.anchor
+	}
+	return m.anchor, m.end
+}
+
+// Is this index into p.Reader() selected?
+func (p *Pager) isSelected(index linemetadata.Index) bool {
+	m, ok := p.mode.(*PagerModeSelect)
+	if !ok {
+		return false
+	}
+
+	first, last := m.selectedRange()
+	return !index.IsBefore(first) && !index.IsAfter(last)
+}
+
+// Move the end of the selection, and scroll to keep it visible
+func (m *PagerModeSelect) moveEnd(delta int) {
+	p := m.pager
+
+	lineCount := p.Reader().GetLineCount()
+	if lineCount == 0 {
+		return
+	}
+
+	m.end = m.end.NonWrappingAdd(delta)
+	if !m.end.IsWithinLength(lineCount) {
+		m.end = *linemetadata.IndexFromLength(lineCount)
+	}
+
+	p.scrollToShowIndex(m.end)
+}
+
+// Scroll as little as possible to get the line on screen
+func (p *Pager) scrollToShowIndex(index linemetadata.Index) {
+	top := p.lineIndex()
+	if top == nil {
+		return
+	}
+
+	if index.IsBefore(*top) {
+		p.scrollPosition = NewScrollPositionFromIndex(index, "scrollToShowIndex")
+		return
+	}
+
+	last := p.getLastVisibleLineIndex()
+	if last != nil && index.IsAfter(*last) {
+		// Put the line at the bottom of the screen
+		p.scrollPosition = NewScrollPositionFromIndex(index, "scrollToShowIndex").PreviousLine(p.visibleHeight() - 1)
+	}
+}
+
+// Copy the selected lines to the clipboard and go back to viewing
+func (m *PagerModeSelect) copySelection(withColors bool) {
+	first, last := m.selectedRange()
+	m.pager.copyLines(first, last, withColors)
+}
+
+// Copy lines from p.Reader() to the clipboard, and tell the user about it
+func (p *Pager) copyLines(first linemetadata.Index, last linemetadata.Index, withColors bool) {
+	lines := fileLinesOnly(p.Reader().GetLines(first, first.CountLinesTo(last)).Lines)
+
+	texts := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if withColors {
+			texts = append(texts, line.Line.Raw())
+		} else {
+			texts = append(texts, line.Plain())
+		}
+	}
+
+	err := p.screen.CopyToClipboard(strings.Join(texts, "\n"))
+	if err != nil {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Copying failed: " + err.Error()}
+		return
+	}
+
+	text := fmt.Sprintf("Copied %d lines to the clipboard", len(texts))
+	if len(texts) == 1 {
+		text = "Copied 1 line to the clipboard"
+	}
+	p.mode = &PagerModeInfo{Pager: p, Text: text}
+}
+
+func (m *PagerModeSelect) drawFooter(_ string, _ string, _ string) {
+	p := m.pager
+
+	first, last := m.selectedRange()
+	count := first.CountLinesTo(last)
+	status := fmt.Sprintf("%d lines selected", count)
+	if count == 1 {
+		status = "1 line selected"
+	}
+
+	p.setFooter("", "", status, "Press 'y' to copy, 'Y' to copy with colors, 'ESC' to cancel")
+}
+
+// Movement keys move the end of the selection, using the same keys as for
+// scrolling
+func (m *PagerModeSelect) onAction(pressed action) {
+	p := m.pager
+
+	switch pressed {
+	case actionScrollUp:
+		m.moveEnd(-1)
+	case actionScrollDown:
+		m.moveEnd(1)
+	case actionPageUp:
+		m.moveEnd(-int(p.visibleHeight()))
+	case actionPageDown:
+		m.moveEnd(int(p.visibleHeight()))
+	case actionHalfPageUp:
+		m.moveEnd(-int(p.visibleHeight() / 2))
+	case actionHalfPageDown:
+		m.moveEnd(int(p.visibleHeight() / 2))
+	case actionGoToStart:
+		m.moveEnd(-m.end.Index())
+	case actionGoToEnd:
+		m.moveEnd(p.Reader().GetLineCount())
+	case actionQuit:
+		p.mode = PagerModeViewing{pager: p}
+	default:
+		log.Debugf("Action %s not supported while selecting", pressed)
+	}
+}
+
+func (m *PagerModeSelect) onKey(key twin.KeyCode) {
+	p := m.pager
+
+	switch key {
+	case twin.KeyEnter:
+		m.copySelection(false)
+
+	case twin.KeyEscape:
+		p.mode = PagerModeViewing{pager: p}
+
+	default:
+		pressed, found := p.keymap.actionForKey(key)
+		if !found {
+			log.Debugf("Unhandled selecting key event %v", key)
+			return
+		}
+		m.onAction(pressed)
+	}
+}
+
+func (m *PagerModeSelect) onRune(char rune) {
+	switch char {
+	case 'y':
+		m.copySelection(false)
+
+	case 'Y':
+		m.copySelection(true)
+
+	default:
+		pressed, found := m.pager.keymap.actionForRune(char)
+		if !found {
+			log.Debugf("Unhandled selecting rune keypress '%s'/0x%08x", string(char), int32(char))
+			return
+		}
+		m.onAction(pressed)
+	}
+}
+
+// The index into p.Reader() of the line on a screen row, clamped to the visible
+// lines. Returns nil if there are no lines.
+func (p *Pager) lineIndexAtRow(row int) *linemetadata.Index {
+	lines := p.renderLines().lines
+	if len(lines) == 0 {
+		return nil
+	}
+
+	row = max(0, min(row, len(lines)-1))
+	return &lines[row].inputLineIndex
+}
+
+// Start selecting on left button presses, and select more lines when dragging.
+// Dragging past the top or the bottom of the screen scrolls.
+func (p *Pager) onMouseSelect(event twin.EventMouse) {
+	if p.isShowingHelp {
+		return
+	}
+
+	column, row := event.Position()
+	if subScreen, ok := p.screen.(*twin.SubScreen); ok {
+		// Make the position relative to the focused pane
+		paneColumn, paneRow := subScreen.Origin()
+		column -= paneColumn
+		row -= paneRow
+	}
+
+	switch event.Action() {
+	case twin.MouseActionPress:
+		if event.Buttons() != twin.MouseButtonLeft {
+			return
+		}
+
+		width, _ := p.screen.Size()
+		if column < 0 || column >= width || row < 0 || row >= int(p.visibleHeight()) {
+			// Not on the focused pane's contents
+			p.dragStart = nil
+			return
+		}
+
+		if _, selecting := p.mode.(*PagerModeSelect); selecting {
+			// Clicking elsewhere deselects
+			p.mode = PagerModeViewing{pager: p}
+		}
+		if !p.isViewing() {
+			return
+		}
+
+		p.dragStart = p.lineIndexAtRow(row)
+		if p.cursor != nil && p.dragStart != nil {
+			// Clicking moves the cursor
+			clicked := *p.dragStart
+			p.cursor = &clicked
+		}
+
+	case twin.MouseActionMotion:
+		if p.dragStart == nil || event.Buttons() != twin.MouseButtonLeft {
+			return
+		}
+
+		if row < 0 {
+			p.scrollPosition = p.scrollPosition.PreviousLine(1)
+		} else if row >= int(p.visibleHeight()) {
+			p.scrollPosition = p.scrollPosition.NextLine(1)
+		}
+
+		end := p.lineIndexAtRow(row)
+		if end == nil {
+			return
+		}
+
+		if m, selecting := p.mode.(*PagerModeSelect); selecting {
+			m.end = *end
+			return
+		}
+		if p.isViewing() {
+			p.mode = &PagerModeSelect{pager: p, anchor: *p.dragStart, end: *end}
+		}
+
+	case twin.MouseActionRelease:
+		// Any selection stays until the user copies it or cancels
+		p.dragStart = nil
+	}
+}
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func newSelectTestPager(t *testing.T, text string) (*Pager, *twin.FakeScreen) {
+	screen := twin.NewFakeScreen(20, 4)
+	pager := newTestPager(t, screen, reader.NewFromTextForTesting("TestSelect", text))
+	return pager, screen
+}
+
+func TestSelectAndCopy(t *testing.T) {
+	pager, screen := newSelectTestPager(t, "\x1b[31mred\x1b[m\nb\nc\nd\ne")
+
+	pager.mode.onRune('L')
+	assert.Equal(t, "Select", modeName(pager))
+
+	pager.mode.onRune('j')
+	pager.redraw("")
+	assert.Assert(t, screen.GetRow(0)[0].Style.HasAttr(twin.AttrReverse))
+	assert.Assert(t, screen.GetRow(1)[0].Style.HasAttr(twin.AttrReverse))
+	assert.Assert(t, !screen.GetRow(2)[0].Style.HasAttr(twin.AttrReverse))
+
+	pager.mode.onRune('y')
+	assert.Equal(t, "Info", modeName(pager))
+	assert.Equal(t, screen.Clipboard(), "red\nb")
+
+	// Selecting upwards, with colors
+	pager.scrollPosition = NewScrollPositionFromIndex(linemetadata.IndexFromZeroBased(1), "TestSelectAndCopy")
+	pager.mode = PagerModeViewing{pager: pager}
+	pager.mode.onRune('L')
+	pager.mode.onKey(twin.KeyUp)
+	pager.mode.onRune('Y')
+	assert.Equal(t, screen.Clipboard(), "\x1b[31mred\x1b[m\nb")
+}
+
+func TestSelectScrolls(t *testing.T) {
+	pager, screen := newSelectTestPager(t, "a\nb\nc\nd\ne\nf")
+
+	// Three lines visible, moving the end of the selection below them should
+	// scroll
+	pager.mode.onRune('L')
+	pager.mode.onRune('j')
+	pager.mode.onRune('j')
+	pager.mode.onRune('j')
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "b")
+	assert.Equal(t, rowToString(screen.GetRow(2)), "d")
+
+	pager.mode.onKey(twin.KeyEscape)
+	assert.Equal(t, "Viewing", modeName(pager))
+	assert.Equal(t, screen.Clipboard(), "")
+}
+
+// Terminals drop clipboard contents that are too large, tell the user instead
+func TestSelectTooMuchToCopy(t *testing.T) {
+	pager, screen := newSelectTestPager(t, numberedLines(strings.Repeat("x", 100), 1000))
+
+	pager.mode.onRune('L')
+	pager.mode.onRune('>')
+	pager.mode.onRune('y')
+	assert.Equal(t, screen.Clipboard(), "")
+
+	info, isInfo := pager.mode.(*PagerModeInfo)
+	assert.Assert(t, isInfo)
+	assert.Assert(t, strings.HasPrefix(info.Text, "Copying failed: "), info.Text)
+}
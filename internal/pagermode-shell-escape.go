@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/twin"
+)
+
+// Asks for a shell command to run
+type PagerModeShellEscape struct {
+	pager    *Pager
+	inputBox InputBox
+}
+
+// Enter shell escape mode, or explain why not
+func (p *Pager) startShellEscape() {
+	if os.Getenv("LESSSECURE") == "1" {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Not running shell commands since LESSSECURE=1 is set in the environment"}
+		return
+	}
+
+	p.mode = &PagerModeShellEscape{
+		pager: p,
+		inputBox: InputBox{
+			accept: INPUTBOX_ACCEPT_ALL,
+		},
+	}
+}
+
+func (m *PagerModeShellEscape) drawFooter(_ string, _ string, _ string) {
+	m.inputBox.draw(m.pager.screen, "'%' is the file name, 'ENTER' runs, 'ESC' cancels", "!")
+}
+
+func (m *PagerModeShellEscape) onKey(key twin.KeyCode) {
+	p := m.pager
+
+	if m.inputBox.handleKey(key) {
+		return
+	}
+
+	switch key {
+	case twin.KeyEnter:
+		if strings.TrimSpace(m.inputBox.text) == "" {
+			p.mode = PagerModeViewing{pager: p}
+			return
+		}
+		p.runShellCommand(m.inputBox.text)
+
+	case twin.KeyEscape:
+		p.mode = PagerModeViewing{pager: p}
+
+	default:
+		log.Debugf("Unhandled shell escape key event %v", key)
+	}
+}
+
+func (m *PagerModeShellEscape) onRune(char rune) {
+	if char == '\x03' {
+		// CTRL-c
+		m.pager.mode = PagerModeViewing{pager: m.pager}
+		return
+	}
+
+	m.inputBox.handleRune(char)
+}
@@ -0,0 +1,334 @@
+// Split screen panes, each showing its own file at its own position.
+//
+// The focused pane uses the Pager's own view state, with p.screen being the
+// focused pane's part of the screen. The other panes' view states are stored
+// in p.panes, and swapped in when they get focus.
+
+package internal
+
+import (
+	"fmt"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/internal/search"
+	"github.com/walles/moor/v2/twin"
+)
+
+// Smaller panes than this aren't useful
+const minPaneWidth = 10
+const minPaneHeight = 3
+
+type paneState struct {
+	reader              *reader.ReaderImpl
+	scrollPosition      scrollPosition
+	leftColumnZeroBased int
+	showLineNumbers     bool
+	targetLine          *linemetadata.Index
+	search              search.Search
+}
+
+func (p *Pager) isSplit() bool {
+	return len(p.panes) > 0
+}
+
+func (p *Pager) paneState() paneState {
+	p.readerLock.Lock()
+	r := p.readers[p.currentReader]
+	p.readerLock.Unlock()
+
+	return paneState{
+		reader:              r,
+		scrollPosition:      p.scrollPosition,
+		leftColumnZeroBased: p.leftColumnZeroBased,
+		showLineNumbers:     p.showLineNumbers,
+		targetLine:          p.TargetLine,
+		search:              p.search,
+	}
+}
+
+// Make the state the Pager's own. Filters are for the focused pane only, so
+// they are cleared.
+func (p *Pager) restorePaneState(state paneState) {
+	p.readerLock.Lock()
+	newIndex := slices.Index(p.readers, state.reader)
+	switched := newIndex >= 0 && newIndex != p.currentReader
+	if switched {
+		p.switchToFile(newIndex)
+	}
+	p.readerLock.Unlock()
+
+	if switched {
+		select {
+		case p.readerSwitched <- struct{}{}:
+		default:
+		}
+	} else {
+		// switchToFile() clears the filters otherwise
+		p.clearFilters()
+	}
+
+	if newIndex < 0 {
+		// The file was closed, stay on the current one
+		log.Debugf("File of pane %d was closed, not restoring its position", p.focusedPane)
+		return
+	}
+
+	p.scrollPosition = state.scrollPosition
+	p.leftColumnZeroBased = state.leftColumnZeroBased
+	p.showLineNumbers = state.showLineNumbers
+	p.search = state.search
+	p.setTargetLine(state.targetLine)
+}
+
+// Split the focused pane in two, with the new pane below it or to the right of
+// it. The new pane starts out showing the same thing as the focused one, and
+// gets focus.
+func (p *Pager) split(vertically bool) {
+	if p.isShowingHelp {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Splitting the help text is not supported"}
+		return
+	}
+
+	if p.isSplit() && p.splitVertically != vertically {
+		p.mode = &PagerModeInfo{Pager: p, Text: "All panes are split the same way, close panes with " + p.keymap.describeFirstKey(actionClosePane) + " to split the other way"}
+		return
+	}
+
+	fullScreen := p.screen
+	paneCount := 2
+	if p.isSplit() {
+		fullScreen = p.fullScreen
+		paneCount = len(p.panes) + 1
+	}
+	width, height := fullScreen.Size()
+	if (vertically && (width-(paneCount-1))/paneCount < minPaneWidth) || (!vertically && height/paneCount < minPaneHeight) {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Not enough room for another pane"}
+		return
+	}
+
+	if !p.isSplit() {
+		p.fullScreen = p.screen
+		p.panes = []paneState{{}}
+		p.focusedPane = 0
+	}
+	p.splitVertically = vertically
+
+	state := p.paneState()
+	p.panes[p.focusedPane] = state
+	p.panes = slices.Insert(p.panes, p.focusedPane+1, state)
+	p.focusedPane++
+	p.layOutPanes()
+}
+
+// For the ':split' and ':vsplit' commands. An empty path means not opening any
+// file.
+func (p *Pager) splitAndOpen(vertically bool, path string) error {
+	paneCount := len(p.panes)
+	p.split(vertically)
+	if len(p.panes) == paneCount {
+		// Splitting failed, the reason is in p.mode
+		return nil
+	}
+
+	if path == "" {
+		return nil
+	}
+	return p.openFile(path)
+}
+
+func (p *Pager) focusNextPane() {
+	if !p.isSplit() {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Only one pane, press " + p.keymap.describeFirstKey(actionSplitBelow) + " to split the screen"}
+		return
+	}
+
+	p.panes[p.focusedPane] = p.paneState()
+	p.focusedPane = (p.focusedPane + 1) % len(p.panes)
+	p.restorePaneState(p.panes[p.focusedPane])
+	p.layOutPanes()
+}
+
+func (p *Pager) closePane() {
+	if !p.isSplit() {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Only one pane, nothing to close"}
+		return
+	}
+
+	p.panes = slices.Delete(p.panes, p.focusedPane, p.focusedPane+1)
+	p.focusedPane = min(p.focusedPane, len(p.panes)-1)
+	p.restorePaneState(p.panes[p.focusedPane])
+
+	if len(p.panes) == 1 {
+		// Not split any more
+		p.screen = p.fullScreen
+		p.fullScreen = nil
+		p.panes = nil
+		p.focusedPane = 0
+		return
+	}
+
+	p.layOutPanes()
+}
+
+// Close all panes except for the focused one
+func (p *Pager) closeOtherPanes() {
+	if !p.isSplit() {
+		return
+	}
+
+	p.screen = p.fullScreen
+	p.fullScreen = nil
+	p.panes = nil
+	p.focusedPane = 0
+}
+
+// Call when a file is closed. Panes showing it will show the current file
+// instead.
+func (p *Pager) forgetPaneReader(closed *reader.ReaderImpl) {
+	p.readerLock.Lock()
+	current := p.readers[p.currentReader]
+	p.readerLock.Unlock()
+
+	for i := range p.panes {
+		if p.panes[i].reader == closed {
+			p.panes[i] = paneState{
+				reader:          current,
+				scrollPosition:  newScrollPosition("Pane after closing file"),
+				showLineNumbers: p.ShowLineNumbers,
+			}
+		}
+	}
+}
+
+// Divide the screen between the panes, and point p.screen to the focused
+// pane's part of it. Returns one screen per pane.
+func (p *Pager) layOutPanes() []*twin.SubScreen {
+	width, height := p.fullScreen.Size()
+	count := len(p.panes)
+
+	screens := make([]*twin.SubScreen, 0, count)
+	if p.splitVertically {
+		// One column between each pane for separators
+		available := width - (count - 1)
+		column := 0
+		for i := range count {
+			paneWidth := available / count
+			if i < available%count {
+				paneWidth++
+			}
+			screens = append(screens, twin.NewSubScreen(p.fullScreen, column, 0, paneWidth, height))
+			column += paneWidth + 1
+		}
+	} else {
+		row := 0
+		for i := range count {
+			paneHeight := height / count
+			if i < height%count {
+				paneHeight++
+			}
+			screens = append(screens, twin.NewSubScreen(p.fullScreen, 0, row, width, paneHeight))
+			row += paneHeight
+		}
+	}
+
+	p.screen = screens[p.focusedPane]
+	return screens
+}
+
+// Draw the panes without focus, and the separators between panes. The focused
+// pane is drawn by redraw().
+func (p *Pager) drawOtherPanes() {
+	if !p.isSplit() {
+		return
+	}
+
+	screens := p.layOutPanes()
+	p.fullScreen.Clear()
+	p.syncDiffPanes()
+
+	for i, screen := range screens {
+		if i == p.focusedPane {
+			continue
+		}
+		p.drawPane(&p.panes[i], screen)
+	}
+
+	if !p.splitVertically {
+		// The status bars separate the panes
+		return
+	}
+
+	_, height := p.fullScreen.Size()
+	column := 0
+	for _, screen := range screens[:len(screens)-1] {
+		width, _ := screen.Size()
+		column += width
+		for row := range height {
+			p.fullScreen.SetCell(column, row, twin.NewStyledRune('│', lineNumbersStyle))
+		}
+		column++
+	}
+}
+
+// Draw a pane without focus, using a Pager of its own
+func (p *Pager) drawPane(state *paneState, screen twin.Screen) {
+	p.readerLock.Lock()
+	readers := p.readers
+	readerIndex := slices.Index(readers, state.reader)
+	p.readerLock.Unlock()
+	if readerIndex < 0 {
+		log.Debugf("Not drawing pane of closed file")
+		return
+	}
+
+	panePager := &Pager{
+		readers:                     readers,
+		currentReader:               readerIndex,
+		screen:                      screen,
+		scrollPosition:              state.scrollPosition,
+		leftColumnZeroBased:         state.leftColumnZeroBased,
+		search:                      state.search,
+		highlights:                  p.highlights,
+		keymap:                      p.keymap,
+		ShowLineNumbers:             p.ShowLineNumbers,
+		showLineNumbers:             state.showLineNumbers,
+		ShowStatusBar:               p.ShowStatusBar,
+		WrapLongLines:               p.WrapLongLines,
+		TabSize:                     p.TabSize,
+		ScrollLeftHint:              p.ScrollLeftHint,
+		ScrollRightHint:             p.ScrollRightHint,
+		SideScrollAmount:            p.SideScrollAmount,
+		WithSearchHitLineBackground: p.WithSearchHitLineBackground,
+		Width:                       p.Width,
+		diff:                        p.diff,
+	}
+	panePager.mode = PagerModeViewing{pager: panePager}
+	panePager.filteringReader = FilteringReader{
+		BackingReader: p.viewOf(state.reader),
+		Filter:        &panePager.filter,
+		Stack:         &panePager.filterStack,
+		Context:       &panePager.filterContext,
+	}
+
+	spinner := ""
+	if !state.reader.ReadingDone.Load() {
+		spinner = "..."
+	}
+
+	rendered := panePager.renderLines()
+	panePager.drawRenderedScreen(rendered, spinner)
+	if p.ShowStatusBar {
+		prefix := ""
+		if len(readers) > 1 {
+			prefix = fmt.Sprintf("[%d/%d] ", readerIndex+1, len(readers))
+		}
+		panePager.setFooter(prefix, rendered.filenameText, rendered.statusText, "")
+	}
+
+	// Rendering can adjust the scroll position, to keep it within the file
+	state.scrollPosition = panePager.scrollPosition
+	state.showLineNumbers = panePager.showLineNumbers
+}
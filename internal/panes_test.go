@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func newPanesTestPager(t *testing.T, width int, height int) (*Pager, *twin.FakeScreen) {
+	screen := twin.NewFakeScreen(width, height)
+	pager := newTestPager(t, screen, reader.NewFromTextForTesting("first", numberedLines("line ", 100)), reader.NewFromTextForTesting("second", "other"))
+	return pager, screen
+}
+
+func TestSplitBelow(t *testing.T) {
+	pager, screen := newPanesTestPager(t, 40, 10)
+
+	pager.mode.onRune('S')
+	assert.Equal(t, len(pager.panes), 2)
+	assert.Equal(t, pager.focusedPane, 1)
+	_, height := pager.screen.Size()
+	assert.Equal(t, height, 5)
+
+	// Scroll the bottom pane only
+	pager.scrollPosition = NewScrollPositionFromIndex(linemetadata.IndexFromOneBased(50), "test")
+	pager.redraw("")
+
+	assert.Equal(t, rowToString(screen.GetRow(0)), "line 1")
+	assert.Assert(t, strings.HasPrefix(rowToString(screen.GetRow(4)), "[1/2] first"), rowToString(screen.GetRow(4)))
+	assert.Equal(t, rowToString(screen.GetRow(5)), "line 50")
+
+	// Focus the top pane, and switch file there
+	pager.mode.onRune('\x17') // CTRL-w
+	assert.Equal(t, pager.focusedPane, 0)
+	assert.Equal(t, pager.lineIndex().Index(), 0)
+	pager.nextFile()
+	pager.filteringReader.SetBackingReader(pager.readers[1]) // Done by the event loop in real life
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "other")
+	assert.Equal(t, rowToString(screen.GetRow(5)), "line 50", "Bottom pane should stay where it was")
+
+	// Closing the top pane brings us back to the bottom one, unsplit
+	pager.mode.onRune('X')
+	pager.filteringReader.SetBackingReader(pager.readers[0])
+	assert.Assert(t, !pager.isSplit())
+	assert.Equal(t, pager.screen, twin.Screen(screen))
+	assert.Equal(t, pager.currentReader, 0)
+	assert.Equal(t, pager.lineIndex().Index(), 49)
+}
+
+func TestSplitRight(t *testing.T) {
+	pager, screen := newPanesTestPager(t, 41, 10)
+
+	pager.mode.onRune('V')
+	width, _ := pager.screen.Size()
+	assert.Equal(t, width, 20)
+
+	pager.redraw("")
+	assert.Equal(t, rowToString(screen.GetRow(0)), "line 1              │line 1")
+
+	pager.mode.onRune('S')
+	_, isInfo := pager.mode.(*PagerModeInfo)
+	assert.Assert(t, isInfo, "Mixing split directions should not be possible")
+}
+
+func TestSplitTooSmall(t *testing.T) {
+	pager, _ := newPanesTestPager(t, 15, 10)
+
+	pager.split(true)
+	assert.Assert(t, !pager.isSplit())
+	assert.Equal(t, pager.mode.(*PagerModeInfo).Text, "Not enough room for another pane")
+}
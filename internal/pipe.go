@@ -0,0 +1,214 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/styles"
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+)
+
+type pipeRange int
+
+const (
+	pipeRangeAll      pipeRange = iota // The whole current file
+	pipeRangeVisible                   // The lines on screen
+	pipeRangeFromHere                  // From the current line to the end
+	pipeRangeMark                      // From a mark to the current line
+	pipeRangeFiltered                  // All lines matching the filter
+)
+
+// Leave out filter separators and diff fillers. They are shown on screen, but
+// aren't part of any file, so they shouldn't be copied, saved or piped.
+func fileLinesOnly(lines []reader.NumberedLine) []reader.NumberedLine {
+	result := make([]reader.NumberedLine, 0, len(lines))
+	for _, line := range lines {
+		if line.Line == filterContextSeparator || line.Line == diffFillerLine {
+			continue
+		}
+		result = append(result, line)
+	}
+	return result
+}
+
+// Collect the lines to pipe. The mark is only used for pipeRangeMark.
+func (p *Pager) linesToPipe(what pipeRange, mark rune) []reader.NumberedLine {
+	return fileLinesOnly(p.linesInRange(what, mark))
+}
+
+func (p *Pager) linesInRange(what pipeRange, mark rune) []reader.NumberedLine {
+	switch what {
+	case pipeRangeVisible:
+		return p.renderLines().inputLines
+
+	case pipeRangeFromHere:
+		first := p.currentLineIndex()
+		if first == nil {
+			return nil
+		}
+
+		return p.Reader().GetLines(*first, p.Reader().GetLineCount()-first.Index()).Lines
+
+	case pipeRangeMark:
+		markPosition, ok := p.bookmarks[mark]
+		if !ok {
+			return nil
+		}
+
+		first := markPosition.lineIndex(p)
+		last := p.currentLineIndex()
+		if first == nil || last == nil {
+			return nil
+		}
+		if first.IsAfter(*last) {
+			first, last = last, first
+		}
+
+		return p.Reader().GetLines(*first, first.CountLinesTo(*last)).Lines
+
+	case pipeRangeFiltered:
+		return p.filteringReader.getAllLines()
+
+	default:
+		p.readerLock.Lock()
+		r := p.readers[p.currentReader]
+		p.readerLock.Unlock()
+
+		return r.GetLines(linemetadata.Index{}, math.MaxInt).Lines
+	}
+}
+
+// Run a command line through the user's shell
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "sh"
+	}
+	return exec.Command(shell, "-c", command)
+}
+
+// Wait for the user to press RETURN. Only call this while the screen is paused.
+func waitForReturn() {
+	fmt.Print("\nPress RETURN to continue")
+
+	// Since os.Stdin might come from a pipe, we read from os.Stdout, which
+	// points to the terminal. See handleEditingRequest() for details.
+	terminal := os.Stdout
+	if runtime.GOOS == "windows" {
+		terminal = os.Stdin
+	}
+
+	_, err := bufio.NewReader(terminal).ReadString('\n')
+	if err != nil {
+		log.Debugf("Waiting for RETURN failed: %v", err)
+	}
+}
+
+// Create a new reader for text generated while paging, highlighted just like
+// the readers we started with
+func (p *Pager) newReaderFromBytes(name string, text []byte) (*reader.ReaderImpl, error) {
+	formatter, options, settings := p.newReaderOptions(name)
+	r, err := reader.NewFromStream(name, bytes.NewReader(text), formatter, options)
+	if err != nil {
+		return nil, err
+	}
+
+	p.SetFileSettings(r, settings)
+	return r, nil
+}
+
+// For reading new files just like the ones we started with, honoring the
+// command line options and any file type settings for this file name
+func (p *Pager) newReaderOptions(name string) (chroma.Formatter, reader.ReaderOptions, FileSettings) {
+	var formatter chroma.Formatter
+	if p.chromaFormatter != nil {
+		formatter = *p.chromaFormatter
+	}
+
+	style := p.chromaStyle
+	if style == nil {
+		// Without a style the reader would wait for one forever
+		style = styles.Fallback
+	}
+
+	options := reader.ReaderOptions{}
+	settings := FileSettings{}
+	if p.OptionsForFile != nil {
+		options, settings = p.OptionsForFile(name)
+	}
+	options.Style = style
+
+	return formatter, options, settings
+}
+
+// Pipe the lines into the command. If openOutput is true, the output is opened
+// as a new file. Otherwise it's shown on the terminal until the user presses
+// RETURN.
+func (p *Pager) pipe(lines []reader.NumberedLine, command string, openOutput bool) {
+	if os.Getenv("LESSSECURE") == "1" {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Not piping since LESSSECURE=1 is set in the environment"}
+		return
+	}
+
+	var input strings.Builder
+	for _, line := range lines {
+		input.WriteString(line.Plain())
+		input.WriteString("\n")
+	}
+
+	var output bytes.Buffer
+	err := p.screen.PauseAndCall(func() error {
+		log.Infof("Piping %d lines into: %s", len(lines), command)
+
+		cmd := shellCommand(command)
+		cmd.Stdin = strings.NewReader(input.String())
+		cmd.Stderr = os.Stderr
+		cmd.Stdout = os.Stdout
+		if openOutput {
+			cmd.Stdout = &output
+		}
+
+		err := cmd.Run()
+		if err != nil || !openOutput {
+			// Give the user a chance to see any output or error messages
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "\n%s: %v\n", command, err)
+			}
+			waitForReturn()
+		}
+
+		return err
+	})
+	if err != nil {
+		log.Infof("Piping into %q failed: %v", command, err)
+		p.mode = &PagerModeInfo{Pager: p, Text: "Command failed: " + err.Error()}
+		return
+	}
+
+	if !openOutput {
+		p.mode = PagerModeViewing{pager: p}
+		return
+	}
+
+	r, err := p.newReaderFromBytes(command, output.Bytes())
+	if err != nil {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Opening command output failed: " + err.Error()}
+		return
+	}
+
+	p.addReader(r)
+	p.mode = PagerModeViewing{pager: p}
+}
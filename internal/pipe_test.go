@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func TestPipeToNewReader(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Needs a Unix shell")
+	}
+
+	backing := reader.NewFromTextForTesting("test", "one\ntwo\nthree")
+	assert.NilError(t, backing.Wait())
+
+	pager := NewPager(backing)
+	pager.screen = twin.NewFakeScreen(40, 10)
+
+	pager.pipe(pager.linesToPipe(pipeRangeAll, 0), "tr a-z A-Z", true)
+	assert.Equal(t, len(pager.readers), 2)
+	assert.Equal(t, pager.currentReader, 1)
+
+	output := pager.readers[1]
+	assert.NilError(t, output.Wait())
+	assert.Equal(t, output.GetLineCount(), 3)
+	assert.Equal(t, output.GetLine(linemetadata.Index{}).Plain(), "ONE")
+	assert.Equal(t, *output.DisplayName, "tr a-z A-Z")
+}
+
+func TestPipeWaitsForReadingDone(t *testing.T) {
+	backing := reader.NewFromTextForTesting("test", "partial")
+	assert.NilError(t, backing.Wait())
+	backing.ReadingDone.Store(false) // Pretend there's more to come
+
+	pager := NewPager(backing)
+	pager.screen = twin.NewFakeScreen(40, 10)
+
+	PagerModePipeRange{pager: pager}.onRune('a')
+	assert.Equal(t, pager.mode.(*PagerModeInfo).Text, "Still reading the file, try piping again when it's done")
+
+	pager.mode = PagerModeViewing{pager: pager}
+	PagerModePipeRange{pager: pager}.onRune('h')
+	assert.Equal(t, pager.mode.(*PagerModeInfo).Text, "Still reading the file, try piping again when it's done")
+}
+
+func TestLinesToPipeFromMark(t *testing.T) {
+	numbers := []string{}
+	for i := range 30 {
+		numbers = append(numbers, strconv.Itoa(i))
+	}
+	backing := reader.NewFromTextForTesting("test", strings.Join(numbers, "\n"))
+	assert.NilError(t, backing.Wait())
+
+	pager := NewPager(backing)
+	pager.screen = twin.NewFakeScreen(40, 10)
+	pager.bookmarks = map[rune]scrollPosition{
+		'a': NewScrollPositionFromIndex(linemetadata.IndexFromZeroBased(14), "mark a"),
+	}
+	pager.scrollPosition = NewScrollPositionFromIndex(linemetadata.IndexFromZeroBased(11), "current")
+
+	lines := pager.linesToPipe(pipeRangeMark, 'a')
+	texts := []string{}
+	for _, line := range lines {
+		texts = append(texts, line.Plain())
+	}
+	assert.DeepEqual(t, texts, []string{"11", "12", "13", "14"})
+
+	assert.Equal(t, len(pager.linesToPipe(pipeRangeMark, 'x')), 0, "No such mark")
+}
+
+// Filter separators aren't part of the file, and shouldn't be piped
+func TestLinesToPipeSkipsSeparators(t *testing.T) {
+	pager := newTestPager(t, twin.NewFakeScreen(40, 10), reader.NewFromTextForTesting("test", "a\nb\nX1\nc\nd\ne\nf\nX2\ng"))
+	pager.filterContext = FilterContext{Before: 1, After: 1}
+	typeFilter(pager, "X")
+
+	for _, what := range []pipeRange{pipeRangeFiltered, pipeRangeVisible, pipeRangeFromHere} {
+		texts := []string{}
+		for _, line := range pager.linesToPipe(what, 0) {
+			texts = append(texts, line.Plain())
+		}
+		assert.DeepEqual(t, texts, []string{"b", "X1", "c", "f", "X2", "g"})
+	}
+}
@@ -141,6 +141,7 @@ func newReaderFromStream(reader io.Reader, originalFileName *string, formatter c
 
 		MoreLinesAdded:          make(chan bool, 1),
 		MaybeDone:               make(chan bool, 2),
+		reloadRequested:         make(chan bool, 1),
 		highlightingStyle:       make(chan chroma.Style, 1),
 		doneWaitingForFirstByte: make(chan bool, 1),
 		HighlightingDone:        &highlightingDone,
@@ -195,17 +196,3 @@ func NewFromTextForTesting(name string, text string) *ReaderImpl {
 
 	return returnMe
 }
-
-// Clone creates a new ReaderImpl using the same source file and options.
-func (reader *ReaderImpl) Clone() (*ReaderImpl, error) {
-	if reader.FileName == nil {
-		return nil, nil // Ignore streams
-	}
-
-	reader.RLock()
-	formatter := reader.formatter
-	options := reader.readerOptions
-	reader.RUnlock()
-
-	return NewFromFilename(*reader.FileName, formatter, options)
-}
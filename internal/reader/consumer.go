@@ -15,7 +15,11 @@ import (
 
 // This is the reader's main function. It will be run in a goroutine. First it
 // reads the stream until the end, then starts tailing.
+//
+// Since everything happens on this goroutine, reading, tailing and reloading
+// never run concurrently. Other goroutines request reloads using Reload().
 func (reader *ReaderImpl) readStream(stream io.Reader, formatter chroma.Formatter, options ReaderOptions) {
+	reader.readGeneration.Store(reader.generation.Load())
 	reader.consumeLinesFromStream(stream)
 
 	if closer, ok := stream.(io.Closer); ok {
@@ -26,6 +30,33 @@ func (reader *ReaderImpl) readStream(stream io.Reader, formatter chroma.Formatte
 		}
 	}
 
+	if reader.FileName != nil && reader.reloadPending() {
+		// Reload() was called while we were reading, so what we have is
+		// outdated. Start over.
+		err := reader.reloadFromFile(*reader.FileName)
+		if err != nil {
+			log.Warn("Failed to reload file: ", err)
+		}
+	} else {
+		reader.finishReading(formatter, options)
+	}
+
+	// Tail the file if the stream is coming from a file.
+	// Ref: https://github.com/walles/moor/issues/224
+	err := reader.tailFile()
+	if err != nil {
+		log.Warn("Failed to tail file: ", err)
+	}
+
+	if reader.FileName != nil {
+		// Not tailing anymore, but the user can still ask for reloads
+		reader.handleReloadRequests()
+	}
+}
+
+// Mark reading as done, then highlight what we got and mark highlighting as
+// done.
+func (reader *ReaderImpl) finishReading(formatter chroma.Formatter, options ReaderOptions) {
 	reader.ReadingDone.Store(true)
 	select {
 	case reader.MaybeDone <- true:
@@ -33,13 +64,9 @@ func (reader *ReaderImpl) readStream(stream io.Reader, formatter chroma.Formatte
 	}
 
 	t0 := time.Now()
-	style := <-reader.highlightingStyle
+	style := reader.awaitHighlightingStyle()
 	options.Style = &style
 
-	reader.Lock()
-	reader.readerOptions.Style = &style
-	reader.Unlock()
-
 	highlightFromMemory(reader, formatter, options)
 	log.Debug("highlightFromMemory() took ", time.Since(t0))
 
@@ -48,13 +75,25 @@ func (reader *ReaderImpl) readStream(stream io.Reader, formatter chroma.Formatte
 	case reader.MaybeDone <- true:
 	default:
 	}
+}
 
-	// Tail the file if the stream is coming from a file.
-	// Ref: https://github.com/walles/moor/issues/224
-	err := reader.tailFile()
-	if err != nil {
-		log.Warn("Failed to tail file: ", err)
+// Wait for SetStyleForHighlighting() to be called, unless it already has
+// been. Must only be called from the reader goroutine.
+func (reader *ReaderImpl) awaitHighlightingStyle() chroma.Style {
+	if reader.highlightingStyleReceived {
+		reader.RLock()
+		defer reader.RUnlock()
+		return *reader.readerOptions.Style
 	}
+
+	style := <-reader.highlightingStyle
+	reader.highlightingStyleReceived = true
+
+	reader.Lock()
+	reader.readerOptions.Style = &style
+	reader.Unlock()
+
+	return style
 }
 
 // Pause if we should pause, otherwise not. Pausing means waiting for
@@ -68,6 +107,11 @@ func (reader *ReaderImpl) assumeLockAndMaybePause() {
 			return
 		}
 
+		if reader.reloadPending() {
+			// We're about to start over, no point in pausing
+			return
+		}
+
 		// Release lock while pausing
 		reader.Unlock()
 		reader.setPauseStatus(true)
@@ -155,7 +199,7 @@ func (reader *ReaderImpl) consumeLinesFromStream(stream io.Reader) {
 	inspectionReader := inspectionReader{base: stream}
 
 	awaitingFirstByte := true
-	for !reader.closed.Load() {
+	for !reader.closed.Load() && !reader.reloadPending() {
 		byteBuffer := make([]byte, byteBufferSize)
 		readBytes, err := inspectionReader.Read(byteBuffer)
 
@@ -127,6 +127,7 @@ func highlightFromMemory(reader *ReaderImpl, formatter chroma.Formatter, options
 		return
 	}
 
+	reader.highlighted.Store(true)
 	reader.setText(*highlighted)
 }
 
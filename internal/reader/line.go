@@ -14,27 +14,50 @@ type Line struct {
 	plainTextCache atomic.Pointer[string] // Use line.Plain() to access this field
 }
 
+// Create a line from a string, which can contain ANSI escape codes
+func NewLine(raw string) *Line {
+	return &Line{raw: []byte(raw)}
+}
+
+// A PatternHighlight is a pattern to highlight in its own style, independently of
+// the active search.
+type PatternHighlight struct {
+	Pattern search.Search
+	Style   twin.Style
+}
+
 // Returns a representation of the string split into styled tokens. Any regexp
 // matches are highlighted. A nil regexp means no highlighting.
 //
+// Matches from the highlights are styled with their respective styles. If
+// highlights overlap, later highlights win over earlier ones, and search hits
+// win over all highlights.
+//
 // maxTokensCount: at most this many tokens will be included in the result. If
 // 0, do all runes. For BenchmarkRenderHugeLine() performance.
 func (line *Line) HighlightedTokens(
 	plainTextStyle twin.Style,
 	searchHitStyle twin.Style,
 	activeSearch search.Search,
+	highlights []PatternHighlight,
 	lineIndex linemetadata.Index,
 	maxTokensCount int,
 ) textstyles.StyledRunesWithTrailer {
 	var matchRanges *search.MatchRanges
-	if activeSearch.Active() {
-		// Only look for matches if there is an active search, since if a line
-		// is 250M characters long, line.Plain() can be slow.
+	var highlightRanges []*search.MatchRanges
+	if activeSearch.Active() || len(highlights) > 0 {
+		// Only look for matches if there is an active search or any
+		// highlights, since if a line is 250M characters long, line.Plain()
+		// can be slow.
 		//
 		// This makes the UI responsive when showing a huge line.
 		plain := line.Plain(lineIndex)
 
 		matchRanges = activeSearch.GetMatchRanges(plain)
+
+		for _, highlight := range highlights {
+			highlightRanges = append(highlightRanges, highlight.Pattern.GetMatchRanges(plain))
+		}
 	}
 
 	fromString := textstyles.StyledRunesFromString(plainTextStyle, string(line.raw), &lineIndex, maxTokensCount)
@@ -42,6 +65,13 @@ func (line *Line) HighlightedTokens(
 	lastWasSearchHit := false
 	for _, token := range fromString.StyledRunes {
 		style := token.Style
+		for i := len(highlightRanges) - 1; i >= 0; i-- {
+			if highlightRanges[i].InRange(len(returnRunes)) {
+				style = highlights[i].Style
+				break
+			}
+		}
+
 		searchHit := matchRanges.InRange(len(returnRunes))
 		if searchHit {
 			// Highlight the search hit
@@ -64,6 +94,11 @@ func (line *Line) HighlightedTokens(
 	}
 }
 
+// Returns the line as it was read, including any ANSI escape codes
+func (line *Line) Raw() string {
+	return string(line.raw)
+}
+
 func (line *Line) HasManPageFormatting() bool {
 	return textstyles.HasManPageFormatting(string(line.raw))
 }
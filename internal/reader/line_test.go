@@ -42,7 +42,7 @@ func TestSearchHitSpanningWrapBoundary(t *testing.T) {
 	searchHitStyle := twin.StyleDefault.WithForeground(twin.NewColor16(3))
 
 	// Match runs from indices 3..8 inclusive ("345678")
-	highlighted := line.HighlightedTokens(twin.StyleDefault, searchHitStyle, search.For("345678"), linemetadata.Index{}, 0)
+	highlighted := line.HighlightedTokens(twin.StyleDefault, searchHitStyle, search.For("345678"), nil, linemetadata.Index{}, 0)
 
 	// Sanity: overall line reports having a search hit
 	assert.Assert(t, highlighted.ContainsSearchHit, "Expected overall line to contain search hit")
@@ -66,3 +66,40 @@ func TestSearchHitSpanningWrapBoundary(t *testing.T) {
 		}
 	}
 }
+
+func TestHighlightedTokensWithHighlights(t *testing.T) {
+	line := NewFromTextForTesting("TestHighlightedTokensWithHighlights", "abcdef").GetLine(linemetadata.Index{}).Line
+	searchHitStyle := twin.StyleDefault.WithAttr(twin.AttrReverse)
+	firstStyle := twin.StyleDefault.WithBackground(twin.NewColor16(3))
+	secondStyle := twin.StyleDefault.WithBackground(twin.NewColor16(2))
+
+	highlights := []PatternHighlight{
+		{Pattern: search.For("abcd"), Style: firstStyle},
+		{Pattern: search.For("cde"), Style: secondStyle},
+	}
+	highlighted := line.HighlightedTokens(twin.StyleDefault, searchHitStyle, search.For("e"), highlights, linemetadata.Index{}, 0)
+
+	expectedStyles := []twin.Style{
+		firstStyle,        // a
+		firstStyle,        // b
+		secondStyle,       // c, later highlights win
+		secondStyle,       // d
+		searchHitStyle,    // e, search hits win
+		twin.StyleDefault, // f
+	}
+	assert.Equal(t, len(highlighted.StyledRunes), len(expectedStyles))
+	for i, cell := range highlighted.StyledRunes {
+		assert.Assert(t, cell.Style.Equal(expectedStyles[i]), "Unexpected style at index %d: %v", i, cell.Style)
+		assert.Equal(t, cell.IsSearchHit, i == 4)
+	}
+}
+
+func TestHighlightedTokensHighlightsAreNotSearchHits(t *testing.T) {
+	line := NewFromTextForTesting("TestHighlightedTokensHighlightsAreNotSearchHits", "abc").GetLine(linemetadata.Index{}).Line
+	highlights := []PatternHighlight{
+		{Pattern: search.For("b"), Style: twin.StyleDefault.WithBackground(twin.NewColor16(3))},
+	}
+	highlighted := line.HighlightedTokens(twin.StyleDefault, twin.StyleDefault, search.Search{}, highlights, linemetadata.Index{}, 0)
+
+	assert.Assert(t, !highlighted.ContainsSearchHit)
+}
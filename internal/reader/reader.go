@@ -121,6 +121,10 @@ type ReaderImpl struct {
 	// Highlighting has been completed.
 	HighlightingDone *atomic.Bool
 
+	// True if the lines have been replaced by highlighted versions of
+	// themselves
+	highlighted atomic.Bool
+
 	highlightingStyle chan chroma.Style
 
 	// This channel expects to be read exactly once. All other uses will lead to
@@ -151,6 +155,26 @@ type ReaderImpl struct {
 
 	// Set to true when this reader is discarded.
 	closed atomic.Bool
+
+	// Reload() bumps this to tell the reader goroutine that whatever it is
+	// reading is outdated. The reader goroutine copies it into readGeneration
+	// whenever it starts reading the file from the beginning.
+	//
+	// Ref: reloadPending()
+	generation     atomic.Uint64
+	readGeneration atomic.Uint64
+
+	// Signalled by Reload(), handled by the reader goroutine
+	reloadRequested chan bool
+
+	// Bumped whenever existing lines are replaced rather than appended to.
+	//
+	// Ref: ContentGeneration()
+	contentGeneration atomic.Uint64
+
+	// Set when the reader goroutine has received a style from
+	// SetStyleForHighlighting(). Only accessed from the reader goroutine.
+	highlightingStyleReceived bool
 }
 
 // InputLines contains a number of lines from the reader, plus metadata
@@ -539,6 +563,12 @@ func (reader *ReaderImpl) PumpToStdout() {
 	drainAllLines()
 }
 
+// True if the lines contain escape codes from syntax highlighting, rather than
+// just the ones that were in the input
+func (reader *ReaderImpl) IsHighlighted() bool {
+	return reader.highlighted.Load()
+}
+
 // Replace reader contents with the given text. Consider setting
 // HighlightingDone and signalling the MaybeDone channel afterwards.
 func (reader *ReaderImpl) setText(text string) {
@@ -556,6 +586,7 @@ func (reader *ReaderImpl) setText(text string) {
 
 	reader.Lock()
 	reader.lines = lines
+	reader.contentGeneration.Add(1)
 	reader.Unlock()
 
 	log.Trace("Reader done, contents explicitly set")
@@ -600,10 +631,77 @@ func (reader *ReaderImpl) SetStyleForHighlighting(style chroma.Style) {
 	reader.highlightingStyle <- style
 }
 
+// Reload re-reads the file from the beginning.
+//
+// The actual reloading is done in the background, by the same goroutine that
+// does the initial reading and the tailing. So this is safe to call at any
+// time, even while the initial read is still in progress.
+//
+// Returns false if this reader can't be reloaded because it's not reading from
+// a file.
+func (reader *ReaderImpl) Reload() bool {
+	if reader.FileName == nil {
+		return false
+	}
+
+	log.Debugf("Reload of %s requested", *reader.FileName)
+	reader.generation.Add(1)
+
+	select {
+	case reader.reloadRequested <- true:
+	default:
+		// A reload is already pending, that one will do
+	}
+
+	// Wake up any active pause so that the reader goroutine notices the
+	// request. It will go right back to being paused after the reload if it
+	// needs to.
+	select {
+	case reader.pauseAfterLinesUpdated <- true:
+	default:
+	}
+
+	return true
+}
+
+// SetLexer changes the language used for highlighting, and reloads the file to
+// highlight it again. Returns false if this reader can't be reloaded because
+// it's not reading from a file.
+func (reader *ReaderImpl) SetLexer(lexer chroma.Lexer) bool {
+	if reader.FileName == nil {
+		return false
+	}
+
+	reader.Lock()
+	reader.readerOptions.Lexer = lexer
+	reader.Unlock()
+
+	return reader.Reload()
+}
+
+// ContentGeneration changes whenever the lines of this reader are replaced, by
+// reloading or highlighting for example. As long as it stays the same, lines
+// are only ever appended.
+func (reader *ReaderImpl) ContentGeneration() uint64 {
+	return reader.contentGeneration.Load()
+}
+
+// True if Reload() has been called since the reader goroutine last started
+// reading the file from the beginning.
+func (reader *ReaderImpl) reloadPending() bool {
+	return reader.generation.Load() != reader.readGeneration.Load()
+}
+
 // Close stops background routines and prevents further reading.
 func (reader *ReaderImpl) Close() {
 	reader.closed.Store(true)
 
 	// Unblock any active pause
 	reader.SetPauseAfterLines(math.MaxInt)
+
+	// Unblock any wait for reload requests
+	select {
+	case reader.reloadRequested <- true:
+	default:
+	}
 }
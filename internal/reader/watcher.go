@@ -23,11 +23,38 @@ const (
 
 // reloadFromFile clears the current content and re-reads the file from scratch.
 //
-// FIXME: This must only be called from the tailing goroutine. If called
-// concurrently with consumeLinesFromStream(), both goroutines will interleave
-// line additions and bytesCount will be wrong. Fix this before adding any
-// other callers (e.g. the 'r' key).
+// This must only be called from the reader goroutine, see readStream(). Other
+// goroutines should call Reload() instead. If Reload() is called while we're
+// in here, we'll start over.
 func (reader *ReaderImpl) reloadFromFile(fileName string) error {
+	for {
+		reader.readGeneration.Store(reader.generation.Load())
+
+		err := reader.readFileFromScratch(fileName)
+		if err != nil {
+			return err
+		}
+
+		if !reader.reloadPending() {
+			break
+		}
+
+		log.Debugf("Reload of %s requested while reloading, starting over", fileName)
+	}
+
+	reader.RLock()
+	formatter := reader.formatter
+	options := reader.readerOptions
+	reader.RUnlock()
+
+	reader.finishReading(formatter, options)
+
+	return nil
+}
+
+// Replace the current contents with the contents of the file. Highlighting is
+// left to the caller.
+func (reader *ReaderImpl) readFileFromScratch(fileName string) error {
 	log.Debugf("Reloading file %s from the beginning", fileName)
 
 	stream, _, err := ZOpen(fileName)
@@ -44,6 +71,7 @@ func (reader *ReaderImpl) reloadFromFile(fileName string) error {
 
 	reader.Lock()
 	reader.lines = reader.lines[:0]
+	reader.contentGeneration.Add(1)
 	reader.bytesCount = 0
 	reader.headerBytes = nil
 	reader.endsWithNewline = false
@@ -53,6 +81,7 @@ func (reader *ReaderImpl) reloadFromFile(fileName string) error {
 	}
 	reader.ReadingDone.Store(false)
 	reader.HighlightingDone.Store(false)
+	reader.highlighted.Store(false)
 	reader.Unlock()
 
 	// Signal the pager to redraw the now-empty content
@@ -67,28 +96,28 @@ func (reader *ReaderImpl) reloadFromFile(fileName string) error {
 		return fmt.Errorf("failed to close file %s after reloading: %w", fileName, err)
 	}
 
-	reader.ReadingDone.Store(true)
-	select {
-	case reader.MaybeDone <- true:
-	default:
-	}
+	return nil
+}
 
-	reader.RLock()
-	formatter := reader.formatter
-	options := reader.readerOptions
-	reader.RUnlock()
+// handleReloadRequests serves Reload() calls until the reader is closed. Used
+// after tailing has stopped.
+func (reader *ReaderImpl) handleReloadRequests() {
+	for !reader.closed.Load() {
+		<-reader.reloadRequested
+		if reader.closed.Load() {
+			return
+		}
 
-	if formatter != nil && options.Style != nil {
-		highlightFromMemory(reader, formatter, options)
-	}
+		if !reader.reloadPending() {
+			// Already taken care of
+			continue
+		}
 
-	reader.HighlightingDone.Store(true)
-	select {
-	case reader.MaybeDone <- true:
-	default:
+		err := reader.reloadFromFile(*reader.FileName)
+		if err != nil {
+			log.Warn("Failed to reload file: ", err)
+		}
 	}
-
-	return nil
 }
 
 // readNewBytes reads bytes appended to the file since we last read it.
@@ -304,7 +333,24 @@ func (reader *ReaderImpl) tailFile() error {
 		// NOTE: We could use something like
 		// https://github.com/fsnotify/fsnotify instead of sleeping and polling
 		// here.
-		time.Sleep(1 * time.Second)
+		select {
+		case <-time.After(1 * time.Second):
+		case <-reader.reloadRequested:
+			if reader.closed.Load() {
+				return nil
+			}
+
+			if !reader.reloadPending() {
+				// Already taken care of
+				continue
+			}
+
+			err := reader.reloadFromFile(*fileName)
+			if err != nil {
+				return err
+			}
+			continue
+		}
 
 		shouldContinue, err := reader.tailOnce()
 		if err != nil {
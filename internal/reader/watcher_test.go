@@ -180,6 +180,45 @@ func TestReadRewrittenFile_Replaced(t *testing.T) {
 	assertLines(t, testMe, "Totally different data replaces the whole file")
 }
 
+// Reloading while the initial read is still in progress should start over,
+// without mixing old and new contents.
+func TestReloadWhileReading(t *testing.T) {
+	file, err := os.CreateTemp("", "moor-watcher-test-*.txt")
+	assert.NilError(t, err)
+	t.Cleanup(func() { _ = os.Remove(file.Name()) })
+	_, err = file.WriteString("old 1\nold 2\nold 3\n")
+	assert.NilError(t, err)
+
+	pauseAfterLines := 1
+	testMe, err := NewFromFilename(file.Name(), formatters.TTY16m, ReaderOptions{
+		Style:           styles.Get("native"),
+		PauseAfterLines: &pauseAfterLines,
+	})
+	assert.NilError(t, err)
+
+	waitForCondition(t, testMe.PauseStatus.Load, "waiting for initial read to pause")
+	assertLines(t, testMe, "old 1")
+
+	err = os.WriteFile(file.Name(), []byte("new 1\nnew 2\n"), 0600)
+	assert.NilError(t, err)
+	assert.Assert(t, testMe.Reload())
+
+	// Still paused after one line, but now it should be the new line
+	waitForCondition(t, func() bool {
+		lines := testMe.GetLines(linemetadata.Index{}, 10).Lines
+		return testMe.PauseStatus.Load() && len(lines) == 1 && lines[0].Plain() == "new 1"
+	}, "waiting for reload to pause")
+
+	testMe.SetPauseAfterLines(99)
+	assert.NilError(t, testMe.Wait())
+	assertLines(t, testMe, "new 1", "new 2")
+}
+
+func TestReloadStream(t *testing.T) {
+	testMe := NewFromTextForTesting("", "text")
+	assert.Assert(t, !testMe.Reload())
+}
+
 type fakeFileInfo struct {
 	size    int64
 	modTime time.Time
@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+)
+
+type saveFormat int
+
+const (
+	saveFormatRaw      saveFormat = iota // The whole buffer, with any ANSI escape codes
+	saveFormatFiltered                   // What's in the filtered view, with any ANSI escape codes
+	saveFormatPlain                      // What's in the filtered view, without ANSI escape codes
+)
+
+var errSaveFileExists = errors.New("file exists")
+
+// "~/foo.txt" -> "/home/johan/foo.txt"
+func expandHomeDir(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		log.Infof("Could not get user home dir to expand %s: %v", path, err)
+		return path
+	}
+
+	return filepath.Join(home, path[1:])
+}
+
+// Lines are read on demand, so the end of the file may not have been read yet.
+// If not, keep reading until it has, and tell the user to try again then.
+// Returns false if the user was asked to try again.
+func (p *Pager) requireReadingDone(doing string) bool {
+	p.readerLock.Lock()
+	r := p.readers[p.currentReader]
+	p.readerLock.Unlock()
+
+	if r.ReadingDone.Load() {
+		return true
+	}
+
+	r.SetPauseAfterLines(math.MaxInt)
+	p.mode = &PagerModeInfo{Pager: p, Text: "Still reading the file, try " + doing + " again when it's done"}
+	return false
+}
+
+func (p *Pager) linesToSave(r *reader.ReaderImpl, format saveFormat) []reader.NumberedLine {
+	if format == saveFormatRaw || !p.isFiltering() {
+		return r.GetLines(linemetadata.Index{}, math.MaxInt).Lines
+	}
+
+	return fileLinesOnly(p.filteringReader.getAllLines())
+}
+
+// Write the lines to a file. Unless overwrite is true, existing files are left
+// alone and errSaveFileExists is returned.
+func saveLines(path string, lines []reader.NumberedLine, withEscapeCodes bool, overwrite bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if overwrite {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(path, flags, 0o666)
+	if errors.Is(err, os.ErrExist) {
+		return errSaveFileExists
+	}
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	for _, line := range lines {
+		text := line.Plain()
+		if withEscapeCodes {
+			text = line.Line.Raw()
+		}
+
+		_, err = writer.WriteString(text + "\n")
+		if err != nil {
+			_ = file.Close()
+			return err
+		}
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Save to the given path, asking for confirmation before overwriting anything
+func (p *Pager) save(path string, format saveFormat, overwrite bool) {
+	if os.Getenv("LESSSECURE") == "1" {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Not saving since LESSSECURE=1 is set in the environment"}
+		return
+	}
+
+	p.readerLock.Lock()
+	r := p.readers[p.currentReader]
+	p.readerLock.Unlock()
+
+	// Escape codes from our own syntax highlighting weren't in the input, so
+	// they are left out
+	withEscapeCodes := format != saveFormatPlain && !r.IsHighlighted()
+
+	if !p.requireReadingDone("saving") {
+		return
+	}
+
+	lines := p.linesToSave(r, format)
+	err := saveLines(expandHomeDir(path), lines, withEscapeCodes, overwrite)
+	if errors.Is(err, errSaveFileExists) {
+		p.mode = &PagerModeSaveOverwrite{pager: p, path: path, format: format}
+		return
+	}
+	if err != nil {
+		log.Infof("Saving to %s failed: %v", path, err)
+		p.mode = &PagerModeInfo{Pager: p, Text: "Saving failed: " + err.Error()}
+		return
+	}
+
+	lineString := "lines"
+	if len(lines) == 1 {
+		lineString = "line"
+	}
+	p.mode = &PagerModeInfo{Pager: p, Text: fmt.Sprintf("Saved %d %s to %s", len(lines), lineString, path)}
+}
@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/twin"
+	"gotest.tools/v3/assert"
+)
+
+func newSaveTestPager(t *testing.T) *Pager {
+	return newTestPager(t, twin.NewFakeScreen(80, 10), reader.NewFromTextForTesting("test", "\x1b[1mbold\x1b[0m match\nother\nplain match"))
+}
+
+func TestSaveFormats(t *testing.T) {
+	pager := newSaveTestPager(t)
+	typeFilter(pager, "match")
+
+	dir := t.TempDir()
+
+	pager.save(filepath.Join(dir, "raw.txt"), saveFormatRaw, false)
+	contents, err := os.ReadFile(filepath.Join(dir, "raw.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(contents), "\x1b[1mbold\x1b[0m match\nother\nplain match\n")
+
+	pager.save(filepath.Join(dir, "filtered.txt"), saveFormatFiltered, false)
+	contents, err = os.ReadFile(filepath.Join(dir, "filtered.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(contents), "\x1b[1mbold\x1b[0m match\nplain match\n")
+
+	pager.save(filepath.Join(dir, "plain.txt"), saveFormatPlain, false)
+	contents, err = os.ReadFile(filepath.Join(dir, "plain.txt"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(contents), "bold match\nplain match\n")
+	assert.Equal(t, pager.mode.(*PagerModeInfo).Text, "Saved 2 lines to "+filepath.Join(dir, "plain.txt"))
+}
+
+// Filter separators aren't part of the file, and shouldn't be saved
+func TestSaveFilteredWithContext(t *testing.T) {
+	pager := newTestPager(t, twin.NewFakeScreen(80, 10), reader.NewFromTextForTesting("test", "X1\na\nb\nc\nX2"))
+	pager.filterContext = FilterContext{After: 1}
+	typeFilter(pager, "X")
+
+	path := filepath.Join(t.TempDir(), "filtered.txt")
+	pager.save(path, saveFormatPlain, false)
+	contents, err := os.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, string(contents), "X1\na\nX2\n")
+}
+
+func TestSaveLeavesOutHighlighting(t *testing.T) {
+	backing, err := reader.NewFromStream("test.go", strings.NewReader("package main\n"), formatters.TTY16m, reader.ReaderOptions{
+		Lexer: lexers.Get("go"),
+		Style: styles.Get("native"),
+	})
+	assert.NilError(t, err)
+	assert.NilError(t, backing.Wait())
+	assert.Assert(t, backing.IsHighlighted())
+
+	pager := NewPager(backing)
+	pager.screen = twin.NewFakeScreen(80, 10)
+
+	path := filepath.Join(t.TempDir(), "raw.go")
+	pager.save(path, saveFormatRaw, false)
+	contents, err := os.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, string(contents), "package main\n")
+}
+
+func TestSaveWaitsForReadingDone(t *testing.T) {
+	backing := reader.NewFromTextForTesting("test", "partial")
+	assert.NilError(t, backing.Wait())
+	backing.ReadingDone.Store(false) // Pretend there's more to come
+
+	pager := NewPager(backing)
+	pager.screen = twin.NewFakeScreen(80, 10)
+
+	path := filepath.Join(t.TempDir(), "partial.txt")
+	pager.save(path, saveFormatPlain, false)
+	assert.Equal(t, pager.mode.(*PagerModeInfo).Text, "Still reading the file, try saving again when it's done")
+
+	_, err := os.Stat(path)
+	assert.Assert(t, os.IsNotExist(err), "Nothing should have been saved")
+}
+
+func TestSaveAsksBeforeOverwriting(t *testing.T) {
+	pager := newSaveTestPager(t)
+
+	path := filepath.Join(t.TempDir(), "existing.txt")
+	assert.NilError(t, os.WriteFile(path, []byte("precious"), 0o600))
+
+	pager.save(path, saveFormatPlain, false)
+	overwrite, ok := pager.mode.(*PagerModeSaveOverwrite)
+	assert.Assert(t, ok, "Should have asked before overwriting")
+
+	overwrite.onRune('n')
+	contents, err := os.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, string(contents), "precious")
+
+	pager.save(path, saveFormatPlain, false)
+	pager.mode.onRune('y')
+	contents, err = os.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, string(contents), "bold match\nother\nplain match\n")
+}
+
+func TestSaveRespectsLessSecure(t *testing.T) {
+	t.Setenv("LESSSECURE", "1")
+	pager := newSaveTestPager(t)
+
+	pager.startSaving()
+	_, isInfo := pager.mode.(*PagerModeInfo)
+	assert.Assert(t, isInfo, "Saving should be disabled")
+}
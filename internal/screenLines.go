@@ -43,11 +43,32 @@ type renderedScreen struct {
 // the bottom
 func (p *Pager) redraw(spinner string) {
 	log.Trace("redraw called")
+	p.drawOtherPanes()
 	p.screen.Clear()
 	p.longestLineLength = 0
+	p.keepCursorOnScreen()
 
-	lastUpdatedScreenLineNumber := -1
 	renderedScreen := p.renderLines()
+	p.drawRenderedScreen(renderedScreen, spinner)
+
+	// Status line code follows
+
+	statusText := renderedScreen.statusText
+	if p.cursor != nil {
+		statusText = p.cursorStatusText()
+	}
+	if hitsText := p.searchHitsText(renderedScreen); hitsText != "" {
+		statusText += "  " + hitsText
+	}
+
+	p.mode.drawFooter(renderedScreen.filenameText, statusText, spinner)
+
+	p.screen.Show()
+}
+
+// Draw the lines, followed by an end of file marker or the spinner
+func (p *Pager) drawRenderedScreen(renderedScreen renderedScreen, spinner string) {
+	lastUpdatedScreenLineNumber := -1
 	for screenLineNumber, row := range renderedScreen.lines {
 		lastUpdatedScreenLineNumber = screenLineNumber
 		column := 0
@@ -56,8 +77,6 @@ func (p *Pager) redraw(spinner string) {
 		}
 	}
 
-	// Status line code follows
-
 	eofSpinner := spinner
 	if eofSpinner == "" {
 		// This happens when we're done
@@ -68,10 +87,45 @@ func (p *Pager) redraw(spinner string) {
 	for _, cell := range spinnerLine {
 		column += p.screen.SetCell(column, lastUpdatedScreenLineNumber+1, cell.ToStyledRune())
 	}
+}
 
-	p.mode.drawFooter(renderedScreen.filenameText, renderedScreen.statusText, spinner)
+// Returns something like "line 12/340 with hits", or an empty string if there
+// is no search or if we haven't counted the hits yet.
+func (p *Pager) searchHitsText(renderedScreen renderedScreen) string {
+	if p.search.Inactive() {
+		return ""
+	}
 
-	p.screen.Show()
+	// Our filtering reader reads p.filter, which we change without locking.
+	// So the counter gets the unfiltered reader, and filters it by itself.
+	p.readerLock.Lock()
+	backing := p.viewOf(p.readers[p.currentReader])
+	p.readerLock.Unlock()
+	filters := p.activeFilters()
+	if p.isShowingHelp {
+		backing = p.helpReader
+		filters = nil
+	}
+
+	events := p.screen.Events()
+	p.searchHitCounter.update(backing, p.search, filters, p.filterContext, func() {
+		select {
+		case events <- eventSearchHitsCounted{}:
+			// Event delivered
+		default:
+			// Queue full, we'll get redrawn for some other reason soon enough
+		}
+	})
+
+	var currentHit *linemetadata.Index
+	for _, line := range renderedScreen.inputLines {
+		if p.search.Matches(line.Plain()) {
+			currentHit = &line.Index
+			break
+		}
+	}
+
+	return p.searchHitCounter.String(currentHit)
 }
 
 // Render all lines that should go on the screen.
@@ -221,7 +275,7 @@ func (p *Pager) renderLine(line reader.NumberedLine, numberPrefixLength int, hig
 	var wrapped []textstyles.StyledRunesWithTrailer
 	var highlighted textstyles.StyledRunesWithTrailer
 	if p.WrapLongLines {
-		highlighted = line.HighlightedTokens(plainTextStyle, searchHitStyle, p.search, 0)
+		highlighted = line.HighlightedTokens(plainTextStyle, searchHitStyle, p.search, p.highlights, 0)
 
 		wrapped = wrapLine(width-numberPrefixLength, highlighted.StyledRunes)
 	} else {
@@ -231,7 +285,7 @@ func (p *Pager) renderLine(line reader.NumberedLine, numberPrefixLength int, hig
 		//
 		// This is a huge performance gain when dealing with files with
 		// extremeny long lines: https://github.com/walles/moor/issues/358
-		highlighted = line.HighlightedTokens(plainTextStyle, searchHitStyle, p.search, width+p.leftColumnZeroBased+1)
+		highlighted = line.HighlightedTokens(plainTextStyle, searchHitStyle, p.search, p.highlights, width+p.leftColumnZeroBased+1)
 
 		// All on one line
 		wrapped = []textstyles.StyledRunesWithTrailer{{
@@ -241,6 +295,19 @@ func (p *Pager) renderLine(line reader.NumberedLine, numberPrefixLength int, hig
 		}}
 	}
 
+	kind := p.filteredLineKind(line.Index)
+	if diffBackground := p.diffLineBackground(line); diffBackground != nil && kind != filteredLineSeparator {
+		// Changes between the files being compared. Search hit lines are
+		// highlighted on top of this.
+		for i := range wrapped {
+			for j := range wrapped[i].StyledRunes {
+				wrapped[i].StyledRunes[j].Style = wrapped[i].StyledRunes[j].Style.WithBackground(*diffBackground)
+			}
+			wrapped[i].Trailer = wrapped[i].Trailer.WithBackground(*diffBackground)
+		}
+		highlighted.Trailer = highlighted.Trailer.WithBackground(*diffBackground)
+	}
+
 	if highlightSearchHitLines && searchHitLineBackground != nil {
 		// Highlight any sub lines with search hits
 		for i := range wrapped {
@@ -255,11 +322,42 @@ func (p *Pager) renderLine(line reader.NumberedLine, numberPrefixLength int, hig
 		}
 	}
 
+	if p.cursor != nil && *p.cursor == line.Index && kind != filteredLineSeparator {
+		// Search hit lines and selections are highlighted on top of this
+		for i := range wrapped {
+			for j := range wrapped[i].StyledRunes {
+				wrapped[i].StyledRunes[j].Style = wrapped[i].StyledRunes[j].Style.WithBackground(cursorLineBackground)
+			}
+			wrapped[i].Trailer = wrapped[i].Trailer.WithBackground(cursorLineBackground)
+		}
+		highlighted.Trailer = highlighted.Trailer.WithBackground(cursorLineBackground)
+	}
+
+	if p.isSelected(line.Index) && kind != filteredLineSeparator {
+		for i := range wrapped {
+			for j := range wrapped[i].StyledRunes {
+				wrapped[i].StyledRunes[j].Style = wrapped[i].StyledRunes[j].Style.WithAttr(twin.AttrReverse)
+			}
+			wrapped[i].Trailer = wrapped[i].Trailer.WithAttr(twin.AttrReverse)
+		}
+		highlighted.Trailer = highlighted.Trailer.WithAttr(twin.AttrReverse)
+	}
+
+	if kind != filteredLineMatch {
+		// Filter context lines and separators are dimmed so that the matches
+		// stand out
+		for i := range wrapped {
+			for j := range wrapped[i].StyledRunes {
+				wrapped[i].StyledRunes[j].Style = wrapped[i].StyledRunes[j].Style.WithAttr(twin.AttrDim)
+			}
+		}
+	}
+
 	rendered := make([]renderedLine, 0)
 	for wrapIndex, subLine := range wrapped {
 		lineNumber := line.Number
 		visibleLineNumber := &lineNumber
-		if wrapIndex > 0 {
+		if wrapIndex > 0 || kind == filteredLineSeparator || line.Line == diffFillerLine {
 			visibleLineNumber = nil
 		}
 
@@ -285,6 +383,15 @@ func (p *Pager) renderLine(line reader.NumberedLine, numberPrefixLength int, hig
 	return rendered
 }
 
+// Is this line a filter match, a filter context line or a separator between
+// filter context groups?
+func (p *Pager) filteredLineKind(index linemetadata.Index) filteredLineKind {
+	if p.isShowingHelp {
+		return filteredLineMatch
+	}
+	return p.filteringReader.lineKind(index)
+}
+
 // Take a rendered line and decorate as needed:
 //   - Line number, or leading whitespace for wrapped lines
 //   - Scroll left indicator
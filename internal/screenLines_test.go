@@ -321,6 +321,7 @@ func TestShortenedInput(t *testing.T) {
 	pager.scrollToEnd()
 	assert.Equal(t, pager.lineIndex().Index(), 991, "This should have been the effect of calling scrollToEnd()")
 
+	pager.filterHistory = &SearchHistory{}
 	pager.mode = NewPagerModeFilter(&pager)
 	pager.filter = search.For("first") // Match only the first line
 
@@ -359,6 +360,7 @@ func TestShortenedInputManyLines(t *testing.T) {
 	pager.scrollToEnd()
 	assert.Equal(t, pager.lineIndex().Index(), 991, "Should be at the last line before filtering")
 
+	pager.filterHistory = &SearchHistory{}
 	pager.mode = NewPagerModeFilter(&pager)
 	pager.filter = search.For(`^match`)
 
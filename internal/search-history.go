@@ -10,6 +10,7 @@ import (
 	"unicode"
 
 	"github.com/adrg/xdg"
+	"github.com/walles/moor/v2/internal/search"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -34,10 +35,70 @@ Search history semantics:
 
 const maxSearchHistoryEntries = 640 // This should be enough for anyone
 
+// History entries for searches with flags look like "(?moor:rw)pattern". This
+// is not a valid regexp, so it won't collide with any regexp search. Entries
+// without flags are stored as-is, so that they stay compatible with less.
+const searchHistoryFlagsPrefix = "(?moor:"
+
+func searchHistoryEntry(text string, flags search.Flags) string {
+	if text == "" {
+		return ""
+	}
+
+	letters := ""
+	if flags.Regexp {
+		letters += "r"
+	}
+	if flags.Literal {
+		letters += "l"
+	}
+	if flags.CaseSensitive {
+		letters += "c"
+	}
+	if flags.WholeWord {
+		letters += "w"
+	}
+	if letters == "" {
+		return text
+	}
+
+	return searchHistoryFlagsPrefix + letters + ")" + text
+}
+
+func parseSearchHistoryEntry(entry string) (string, search.Flags) {
+	flags := search.Flags{}
+	if !strings.HasPrefix(entry, searchHistoryFlagsPrefix) {
+		return entry, flags
+	}
+
+	letters, text, found := strings.Cut(entry[len(searchHistoryFlagsPrefix):], ")")
+	if !found {
+		return entry, flags
+	}
+
+	for _, letter := range letters {
+		switch letter {
+		case 'r':
+			flags.Regexp = true
+		case 'l':
+			flags.Literal = true
+		case 'c':
+			flags.CaseSensitive = true
+		case 'w':
+			flags.WholeWord = true
+		default:
+			// Not ours after all
+			return entry, search.Flags{}
+		}
+	}
+
+	return text, flags
+}
+
 // A relative path or just a file name means relative to the user's home
 // directory. Empty means follow the XDG spec for data files.
 func BootSearchHistory(fileName string) SearchHistory {
-	fileName = resolveHistoryFilePath(fileName)
+	fileName = resolveHistoryFilePath(fileName, "moor/search_history")
 
 	history, err := loadMoorSearchHistory(fileName)
 	if err != nil {
@@ -77,6 +138,37 @@ func BootSearchHistory(fileName string) SearchHistory {
 	}
 }
 
+// Like BootSearchHistory(), but for filter patterns. Those are kept separate
+// from the search history, and are never imported from less.
+func BootFilterHistory(fileName string) SearchHistory {
+	return bootMoorOnlyHistory(fileName, "moor/filter_history", "filter")
+}
+
+// Like BootFilterHistory(), but for ':' commands
+func BootCommandHistory(fileName string) SearchHistory {
+	return bootMoorOnlyHistory(fileName, "moor/command_history", "command")
+}
+
+func bootMoorOnlyHistory(fileName string, xdgName string, what string) SearchHistory {
+	fileName = resolveHistoryFilePath(fileName, xdgName)
+
+	history, err := loadMoorSearchHistory(fileName)
+	if err != nil {
+		log.Infof("Could not load moor %s history from %s: %v", what, fileName, err)
+		// IO Error, give up
+		return SearchHistory{}
+	}
+	if history == nil {
+		history = []string{}
+	}
+
+	log.Infof("Loaded %d %s history entries from %s", len(history), what, fileName)
+	return SearchHistory{
+		absFileName: fileName,
+		entries:     history,
+	}
+}
+
 // Returns (nil, nil) if the file doesn't exist. Otherwise returns history slice
 // or error.
 func loadMoorSearchHistory(absHistoryFileName string) ([]string, error) {
@@ -111,19 +203,19 @@ func loadMoorSearchHistory(absHistoryFileName string) ([]string, error) {
 	return removeDupsKeepingLast(lines), nil
 }
 
-// Empty file name will resolve to a default XDG friendly path. Absolute will be
-// left untouched. Relative will be interpreted relative to the user's home
-// directory.
-func resolveHistoryFilePath(fileName string) string {
+// Empty file name will resolve to xdgName in the XDG data directory. Absolute
+// will be left untouched. Relative will be interpreted relative to the user's
+// home directory.
+func resolveHistoryFilePath(fileName string, xdgName string) string {
 	if fileName == "-" || fileName == "/dev/null" {
 		// No history file
 		return ""
 	}
 
 	if fileName == "" {
-		xdgPath, err := xdg.DataFile("moor/search_history")
+		xdgPath, err := xdg.DataFile(xdgName)
 		if err != nil {
-			log.Infof("Could not resolve XDG data file path for search history: %v", err)
+			log.Infof("Could not resolve XDG data file path for %s: %v", xdgName, err)
 			return ""
 		}
 		return xdgPath
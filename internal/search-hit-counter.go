@@ -0,0 +1,166 @@
+package internal
+
+import (
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/walles/moor/v2/internal/linemetadata"
+	"github.com/walles/moor/v2/internal/reader"
+	"github.com/walles/moor/v2/internal/search"
+)
+
+// The search hits counter is done counting, or searching all files found more
+// hits. Redraw to show them.
+type eventSearchHitsCounted struct{}
+
+// Counts lines with search hits in the background, for showing "line 12/340
+// with hits" in the status bar.
+type searchHitCounter struct {
+	lock sync.Mutex
+
+	// What the hits were counted for. If any of these change, we start over.
+	backing           reader.Reader
+	contentGeneration uint64
+	search            search.Search
+	filters           []search.Search
+	context           FilterContext
+
+	// What we count in. This is either the backing reader, or our own
+	// filtering view of it. Having our own means that the pager can change
+	// its filters while we count.
+	view reader.Reader
+
+	// Indices into view of all matching lines among its first countedLines
+	// lines, in order
+	hits         []linemetadata.Index
+	countedLines int
+
+	// The backing reader's line count when we last counted. If it changes,
+	// there is more to count.
+	countedBackingLines int
+
+	// Bumped every time we start over, so that results from stale background
+	// counts can be thrown away
+	generation int
+
+	counting bool
+}
+
+// Start counting any lines that haven't been counted yet. Call this whenever
+// there might be new lines, or a new search.
+//
+// The filters are applied to the backing reader before counting, just like
+// the pager does. onCounted will be called from a background goroutine when
+// the count has been updated.
+func (c *searchHitCounter) update(backing reader.Reader, s search.Search, filters []search.Search, context FilterContext, onCounted func()) {
+	c.lock.Lock()
+
+	// Generation first, in case the lines change after this
+	var contentGeneration uint64
+	if generationReader, ok := backing.(contentGenerationReader); ok {
+		contentGeneration = generationReader.ContentGeneration()
+	}
+	backingLineCount := backing.GetLineCount()
+
+	if c.backing != backing ||
+		c.contentGeneration != contentGeneration ||
+		!c.search.Equals(s) ||
+		!slices.EqualFunc(c.filters, filters, search.Search.Equals) ||
+		c.context != context ||
+		backingLineCount < c.countedBackingLines {
+		// Start over
+		c.backing = backing
+		c.contentGeneration = contentGeneration
+		c.search = s
+		c.filters = filters
+		c.context = context
+		c.view = filteredView(backing, filters, context)
+		c.hits = nil
+		c.countedLines = 0
+		c.countedBackingLines = 0
+		c.generation++
+		c.counting = false
+	}
+
+	if s.Inactive() || c.counting || backingLineCount == c.countedBackingLines {
+		c.lock.Unlock()
+		return
+	}
+
+	c.counting = true
+	generation := c.generation
+	view := c.view
+	start := linemetadata.IndexFromZeroBased(c.countedLines)
+	c.lock.Unlock()
+
+	go func() {
+		defer func() {
+			PanicHandler("searchHitCounter.update()", recover(), debug.Stack())
+		}()
+
+		// For filtering views, this is where any new lines get filtered
+		lineCount := view.GetLineCount()
+		hits := FindAllHits(view, s, start, linemetadata.IndexFromZeroBased(lineCount))
+
+		c.lock.Lock()
+		if generation != c.generation {
+			// Search or reader changed while we were counting, never mind
+			c.lock.Unlock()
+			return
+		}
+		c.hits = append(c.hits, hits...)
+		c.countedLines = lineCount
+		c.countedBackingLines = backingLineCount
+		c.counting = false
+		c.lock.Unlock()
+
+		onCounted()
+	}()
+}
+
+// A view of the backing reader with the filters applied, or the backing reader
+// itself if there are no filters
+func filteredView(backing reader.Reader, filters []search.Search, context FilterContext) reader.Reader {
+	if len(filters) == 0 {
+		return backing
+	}
+
+	stack := slices.Clone(filters[:len(filters)-1])
+	filter := filters[len(filters)-1]
+	return &FilteringReader{
+		BackingReader: backing,
+		Filter:        &filter,
+		Stack:         &stack,
+		Context:       &context,
+	}
+}
+
+// Returns something like "line 12/340 with hits" if currentHit is non-nil, or
+// "340 lines with hits" otherwise. Lines with many hits are only counted once.
+// Returns an empty string if nothing has been counted yet.
+//
+// currentHit is the index of the first line with a hit on screen.
+func (c *searchHitCounter) String(currentHit *linemetadata.Index) string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.search.Inactive() || c.countedLines == 0 {
+		return ""
+	}
+
+	if currentHit == nil || currentHit.Index() >= c.countedLines {
+		if len(c.hits) == 1 {
+			return "1 line with hits"
+		}
+		return fmt.Sprintf("%d lines with hits", len(c.hits))
+	}
+
+	number := sort.Search(len(c.hits), func(i int) bool {
+		return !c.hits[i].IsBefore(*currentHit)
+	}) + 1
+
+	return fmt.Sprintf("line %d/%d with hits", number, len(c.hits))
+}
@@ -17,6 +17,22 @@ import (
 // Go for at least this many lines per core when searching
 const minLinesPerCore = 1000
 
+// Split the lines to search into one chunk per core, but without making the
+// chunks too small to be worth it. The last chunk also gets any remaining lines.
+func searchChunks(linesCount int) (chunkCount int, chunkSize int) {
+	chunkCount = runtime.NumCPU()
+
+	maxChunkCount := linesCount / minLinesPerCore
+	if chunkCount > maxChunkCount {
+		chunkCount = maxChunkCount
+	}
+	if chunkCount < 1 {
+		chunkCount = 1
+	}
+
+	return chunkCount, linesCount / chunkCount
+}
+
 // Search input lines. Not screen lines!
 //
 // The `beforePosition` parameter is exclusive, meaning that line will not be
@@ -41,17 +57,7 @@ func FindFirstHit(reader reader.Reader, search search.Search, startPosition line
 		}
 	}
 
-	// How many cores should we use?
-	chunkCount := runtime.NumCPU()
-
-	maxChunkCount := linesCount / minLinesPerCore
-	if chunkCount > maxChunkCount {
-		chunkCount = maxChunkCount
-	}
-	if chunkCount < 1 {
-		chunkCount = 1
-	}
-	chunkSize := linesCount / chunkCount
+	chunkCount, chunkSize := searchChunks(linesCount)
 
 	log.Debugf("Searching %d lines across %d cores with %d lines per core...", linesCount, chunkCount, chunkSize)
 	t0 := time.Now()
@@ -166,3 +172,70 @@ func _findFirstHit(reader reader.Reader, startPosition linemetadata.Index, searc
 		}
 	}
 }
+
+// Find the indices of all input lines matching the search, in order. Not screen
+// lines!
+//
+// The `beforePosition` parameter is exclusive, meaning that line will not be
+// searched.
+//
+// Like FindFirstHit(), this method searches multiple chunks of the input in
+// parallel to help large file search performance.
+func FindAllHits(reader reader.Reader, search search.Search, startPosition linemetadata.Index, beforePosition linemetadata.Index) []linemetadata.Index {
+	linesCount := beforePosition.Index() - startPosition.Index()
+	if linesCount <= 0 {
+		return nil
+	}
+
+	chunkCount, chunkSize := searchChunks(linesCount)
+
+	log.Debugf("Counting hits in %d lines across %d cores with %d lines per core...", linesCount, chunkCount, chunkSize)
+
+	// Make a results array, with one result per chunk
+	findings := make([]chan []linemetadata.Index, chunkCount)
+
+	// Search all chunks in parallel
+	for i := 0; i < chunkCount; i++ {
+		findings[i] = make(chan []linemetadata.Index, 1)
+
+		chunkStart := startPosition.NonWrappingAdd(i * chunkSize)
+		chunkBefore := startPosition.NonWrappingAdd((i + 1) * chunkSize)
+		if i == chunkCount-1 {
+			chunkBefore = beforePosition
+		}
+
+		go func(i int, chunkStart linemetadata.Index, chunkBefore linemetadata.Index) {
+			defer func() {
+				PanicHandler("FindAllHits()/chunkSearch", recover(), debug.Stack())
+			}()
+
+			findings[i] <- _findAllHits(reader, chunkStart, search, chunkBefore)
+		}(i, chunkStart, chunkBefore)
+	}
+
+	// Concatenate the results in order
+	var hits []linemetadata.Index
+	for _, finding := range findings {
+		hits = append(hits, <-finding...)
+	}
+
+	return hits
+}
+
+func _findAllHits(reader reader.Reader, startPosition linemetadata.Index, search search.Search, beforePosition linemetadata.Index) []linemetadata.Index {
+	var hits []linemetadata.Index
+	lineCache := searchLineCache{}
+	for searchPosition := startPosition; searchPosition.IsBefore(beforePosition); searchPosition = searchPosition.NonWrappingAdd(1) {
+		line := lineCache.GetLine(reader, searchPosition, SearchDirectionForward)
+		if line == nil {
+			// No more lines
+			break
+		}
+
+		if search.Matches(line.Plain()) {
+			hits = append(hits, searchPosition)
+		}
+	}
+
+	return hits
+}
@@ -54,6 +54,68 @@ func TestFindFirstHitNoMatchBackwards(t *testing.T) {
 	assert.Assert(t, hit == nil)
 }
 
+func TestFindAllHits(t *testing.T) {
+	// Enough lines to be split into multiple chunks on multi-core machines
+	lines := []string{}
+	for i := 0; i < 5*minLinesPerCore; i++ {
+		if i%7 == 0 {
+			lines = append(lines, fmt.Sprintf("hit %d", i))
+		} else {
+			lines = append(lines, fmt.Sprintf("miss %d", i))
+		}
+	}
+	reader := reader.NewFromTextForTesting("TestFindAllHits", strings.Join(lines, "\n"))
+	assert.NilError(t, reader.Wait())
+
+	hits := FindAllHits(reader, search.For("hit"), linemetadata.Index{}, linemetadata.IndexFromZeroBased(reader.GetLineCount()))
+	assert.Equal(t, len(hits), (len(lines)+6)/7)
+	for i, hit := range hits {
+		assert.Equal(t, hit.Index(), i*7)
+	}
+
+	// Partial range, end is exclusive
+	hits = FindAllHits(reader, search.For("hit"), linemetadata.IndexFromZeroBased(1), linemetadata.IndexFromZeroBased(14))
+	assert.Equal(t, len(hits), 1)
+	assert.Equal(t, hits[0].Index(), 7)
+}
+
+func TestSearchHitCounter(t *testing.T) {
+	impl := reader.NewFromTextForTesting("TestSearchHitCounter", "a\nb\na\nc\na")
+	assert.NilError(t, impl.Wait())
+	backing := &growingReader{ReaderImpl: impl, lineCount: 5, lowestRequested: -1}
+
+	counter := searchHitCounter{}
+	counted := make(chan bool, 1)
+	onCounted := func() { counted <- true }
+	counter.update(backing, search.For("a"), nil, FilterContext{}, onCounted)
+	<-counted
+
+	assert.Equal(t, counter.String(nil), "3 lines with hits")
+
+	secondHit := linemetadata.IndexFromZeroBased(2)
+	assert.Equal(t, counter.String(&secondHit), "line 2/3 with hits")
+
+	// New search, start over
+	counter.update(backing, search.For("c"), nil, FilterContext{}, onCounted)
+	<-counted
+	assert.Equal(t, counter.String(nil), "1 line with hits")
+
+	// Filtering, hits are counted in the filtered view
+	counter.update(backing, search.For("a"), []search.Search{search.For("[ac]")}, FilterContext{}, onCounted)
+	<-counted
+	assert.Equal(t, counter.String(nil), "3 lines with hits")
+	// The filtered lines are "a", "a", "c" and "a"
+	lastFilteredLine := linemetadata.IndexFromZeroBased(3)
+	assert.Equal(t, counter.String(&lastFilteredLine), "line 3/3 with hits")
+
+	// Reloading with the same line count should make us start over
+	backing.generation++
+	counter.update(backing, search.For("a"), []search.Search{search.For("[ac]")}, FilterContext{}, onCounted)
+	<-counted
+	assert.Equal(t, counter.String(nil), "3 lines with hits")
+	assert.Equal(t, backing.lowestRequested, 0)
+}
+
 // Converts a cell row to a plain string and removes trailing whitespace.
 func rowToString(row []twin.StyledRune) string {
 	rowString := ""
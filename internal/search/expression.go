@@ -0,0 +1,236 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Filter expressions combine searches using !, && and ||, with parentheses for
+// grouping. Example: "ERROR && !(retrying || healthcheck)".
+//
+// Terms can be double quoted to include any of the operator characters, like
+// this: "\"(foo|bar)\" && baz".
+
+type expressionNode interface {
+	matches(line string) bool
+
+	// Collect match ranges of all non-negated terms matching the line
+	addMatchRanges(line string, ranges *MatchRanges)
+}
+
+type termNode struct {
+	search Search
+}
+
+type notNode struct {
+	operand expressionNode
+}
+
+type andNode struct {
+	left, right expressionNode
+}
+
+type orNode struct {
+	left, right expressionNode
+}
+
+func (n termNode) matches(line string) bool {
+	return n.search.Matches(line)
+}
+
+func (n termNode) addMatchRanges(line string, ranges *MatchRanges) {
+	found := n.search.GetMatchRanges(line)
+	if found != nil {
+		ranges.Matches = append(ranges.Matches, found.Matches...)
+	}
+}
+
+func (n notNode) matches(line string) bool {
+	return !n.operand.matches(line)
+}
+
+func (n notNode) addMatchRanges(_ string, _ *MatchRanges) {
+	// Negated terms don't match anything that should be highlighted
+}
+
+func (n andNode) matches(line string) bool {
+	return n.left.matches(line) && n.right.matches(line)
+}
+
+func (n andNode) addMatchRanges(line string, ranges *MatchRanges) {
+	n.left.addMatchRanges(line, ranges)
+	n.right.addMatchRanges(line, ranges)
+}
+
+func (n orNode) matches(line string) bool {
+	return n.left.matches(line) || n.right.matches(line)
+}
+
+func (n orNode) addMatchRanges(line string, ranges *MatchRanges) {
+	n.left.addMatchRanges(line, ranges)
+	n.right.addMatchRanges(line, ranges)
+}
+
+// Only strings with operators in them are expressions. This way plain regexps,
+// including ones with parentheses in them, work just like before.
+func isExpression(s string) bool {
+	trimmed := strings.TrimLeftFunc(s, func(r rune) bool {
+		return r == '(' || unicode.IsSpace(r)
+	})
+	if strings.HasPrefix(trimmed, "!") {
+		return true
+	}
+
+	return strings.Contains(s, "&&") || strings.Contains(s, "||")
+}
+
+type expressionParser struct {
+	input []rune
+	pos   int
+	flags Flags
+}
+
+func parseExpression(s string, flags Flags) (expressionNode, error) {
+	parser := expressionParser{input: []rune(s), flags: flags}
+
+	node, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	parser.skipSpaces()
+	if parser.pos < len(parser.input) {
+		return nil, fmt.Errorf("unexpected %q at position %d", string(parser.input[parser.pos]), parser.pos+1)
+	}
+
+	return node, nil
+}
+
+func (p *expressionParser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// Consume the operator if it's next in the input
+func (p *expressionParser) accept(operator string) bool {
+	p.skipSpaces()
+	if !strings.HasPrefix(string(p.input[p.pos:]), operator) {
+		return false
+	}
+
+	p.pos += len([]rune(operator))
+	return true
+}
+
+func (p *expressionParser) parseOr() (expressionNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *expressionParser) parseAnd() (expressionNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *expressionParser) parseUnary() (expressionNode, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+
+	if p.accept("(") {
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing ')' at position %d", p.pos+1)
+		}
+		return node, nil
+	}
+
+	return p.parseTerm()
+}
+
+func (p *expressionParser) parseTerm() (expressionNode, error) {
+	p.skipSpaces()
+	if p.pos < len(p.input) && p.input[p.pos] == '"' {
+		return p.parseQuotedTerm()
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) {
+		rest := string(p.input[p.pos:])
+		if strings.HasPrefix(rest, "&&") || strings.HasPrefix(rest, "||") {
+			break
+		}
+		if p.input[p.pos] == '(' || p.input[p.pos] == ')' {
+			break
+		}
+		p.pos++
+	}
+
+	term := strings.TrimRightFunc(string(p.input[start:p.pos]), unicode.IsSpace)
+	if term == "" {
+		return nil, fmt.Errorf("missing search term at position %d", start+1)
+	}
+
+	return termNode{search: ForWithFlags(term, p.flags)}, nil
+}
+
+func (p *expressionParser) parseQuotedTerm() (expressionNode, error) {
+	start := p.pos
+	p.pos++ // Skip the opening quote
+
+	var term strings.Builder
+	for p.pos < len(p.input) {
+		char := p.input[p.pos]
+		p.pos++
+
+		if char == '"' {
+			if term.Len() == 0 {
+				return nil, fmt.Errorf("empty search term at position %d", start+1)
+			}
+			return termNode{search: ForWithFlags(term.String(), p.flags)}, nil
+		}
+
+		if char == '\\' && p.pos < len(p.input) && p.input[p.pos] == '"' {
+			// Escaped quote
+			char = '"'
+			p.pos++
+		}
+
+		term.WriteRune(char)
+	}
+
+	return nil, fmt.Errorf("missing '\"' after position %d", start+1)
+}
@@ -0,0 +1,74 @@
+package search
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestExpressionMatches(t *testing.T) {
+	notHealthcheck := ForExpression("!healthcheck", Flags{})
+	assert.Assert(t, notHealthcheck.IsExpression())
+	assert.Assert(t, notHealthcheck.Matches("GET /api"))
+	assert.Assert(t, !notHealthcheck.Matches("GET /healthcheck"))
+
+	errorNotRetrying := ForExpression("ERROR && !retrying", Flags{})
+	assert.Assert(t, errorNotRetrying.Matches("ERROR: disk full"))
+	assert.Assert(t, !errorNotRetrying.Matches("ERROR: timeout, retrying"))
+	assert.Assert(t, !errorNotRetrying.Matches("INFO: all good"))
+
+	grouped := ForExpression("(warn || error) && !(retry || ignore)", Flags{})
+	assert.Assert(t, grouped.Matches("warn: x"))
+	assert.Assert(t, grouped.Matches("error: x"))
+	assert.Assert(t, !grouped.Matches("error: will retry"))
+	assert.Assert(t, !grouped.Matches("info: x"))
+
+	// && binds harder than ||
+	precedence := ForExpression("a || b && c", Flags{})
+	assert.Assert(t, precedence.Matches("a"))
+	assert.Assert(t, !precedence.Matches("b"))
+	assert.Assert(t, precedence.Matches("b c"))
+
+	// Quoted terms can contain operator characters
+	quoted := ForExpression(`"(x|y)z" && !"a\"b"`, Flags{})
+	assert.Assert(t, quoted.Matches("yz"))
+	assert.Assert(t, !quoted.Matches(`yz a"b`))
+}
+
+func TestExpressionFallbacks(t *testing.T) {
+	// No operators, plain regexps with parentheses should still work
+	plain := ForExpression("(foo|bar)baz", Flags{})
+	assert.Assert(t, !plain.IsExpression())
+	assert.Assert(t, plain.Matches("barbaz"))
+
+	// Incomplete expression, searched for as-is
+	incomplete := ForExpression("foo &&", Flags{})
+	assert.Assert(t, !incomplete.IsExpression())
+	assert.Assert(t, incomplete.Matches("foo &&"))
+	assert.Assert(t, incomplete.ExpressionError() != nil)
+	assert.NilError(t, plain.ExpressionError())
+	assert.NilError(t, ForExpression("!a", Flags{}).ExpressionError())
+	incomplete.Clear()
+	assert.NilError(t, incomplete.ExpressionError())
+
+	// Expressions and plain searches are different things
+	assert.Assert(t, !ForExpression("!a", Flags{}).Equals(For("!a")))
+}
+
+func TestExpressionMatchRanges(t *testing.T) {
+	expression := ForExpression("ab || cd && !xyz", Flags{})
+
+	// Negated terms are not highlighted
+	ranges := expression.GetMatchRanges("ab cd")
+	assert.DeepEqual(t, ranges.Matches, [][2]int{{0, 2}, {3, 5}})
+
+	// No ranges on lines not matching the whole expression
+	assert.Assert(t, expression.GetMatchRanges("cd xyz") == nil)
+}
+
+func TestExpressionParseErrors(t *testing.T) {
+	for _, broken := range []string{"a &&", "(a || b", "a || ()", `"a`, `"" && a`, "a) || b"} {
+		_, err := parseExpression(broken, Flags{})
+		assert.Assert(t, err != nil, "Expected an error parsing %q", broken)
+	}
+}
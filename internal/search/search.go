@@ -9,33 +9,127 @@ import (
 	"github.com/charlievieth/strcase"
 )
 
+// Flags override how the search string is interpreted. The zero value means
+// regexp if the search string is a valid regexp with special characters in
+// it, and case sensitive only if there are any upper case characters.
+type Flags struct {
+	Regexp        bool // Always a regexp, even without special characters
+	Literal       bool // Never a regexp, even with special characters
+	CaseSensitive bool // Case sensitive even without upper case characters
+	WholeWord     bool // Only match whole words
+}
+
+// String returns a human readable list of the active flags, or an empty
+// string if no flags are set.
+func (flags Flags) String() string {
+	names := []string{}
+	if flags.Regexp {
+		names = append(names, "regexp")
+	}
+	if flags.Literal {
+		names = append(names, "literal")
+	}
+	if flags.CaseSensitive {
+		names = append(names, "case")
+	}
+	if flags.WholeWord {
+		names = append(names, "word")
+	}
+	return strings.Join(names, ", ")
+}
+
 type Search struct {
 	findMe string
 
+	flags Flags
+
 	// If this is false it means the input has to be interpreted as a regexp.
 	isSubstringSearch bool
 
 	hasUppercase bool
 
 	pattern *regexp.Regexp
+
+	// Non-nil for filter expressions. Ref: ForExpression().
+	expression expressionNode
+
+	// Why ForExpression() fell back to searching for the text as-is
+	expressionError error
 }
 
 func (search Search) Equals(other Search) bool {
-	return search.findMe == other.findMe
+	return search.findMe == other.findMe &&
+		search.flags == other.flags &&
+		(search.expression == nil) == (other.expression == nil)
 }
 
 func (search Search) String() string {
 	return search.findMe
 }
 
+func (search Search) Flags() Flags {
+	return search.flags
+}
+
 func For(s string) Search {
 	search := Search{}
 	search.For(s)
 	return search
 }
 
+func ForWithFlags(s string, flags Flags) Search {
+	search := Search{}
+	search.ForWithFlags(s, flags)
+	return search
+}
+
+// Like ForWithFlags(), but if s contains any !, && or || operators, it is
+// treated as a filter expression. Ref: expression.go.
+//
+// If the expression can't be parsed, s is searched for as-is. This happens
+// while the user is still typing an expression, like "foo &&".
+func ForExpression(s string, flags Flags) Search {
+	search := Search{}
+	search.ForExpression(s, flags)
+	return search
+}
+
+func (search *Search) ForExpression(s string, flags Flags) *Search {
+	search.ForWithFlags(s, flags)
+	if !isExpression(s) {
+		return search
+	}
+
+	expression, err := parseExpression(s, flags)
+	if err != nil {
+		search.expressionError = err
+		return search
+	}
+
+	search.expression = expression
+	return search
+}
+
+// True if this is a filter expression, ref ForExpression()
+func (search Search) IsExpression() bool {
+	return search.expression != nil
+}
+
+// If ForExpression() got something that looked like a filter expression but
+// didn't parse, this says what was wrong with it. Nil otherwise.
+func (search Search) ExpressionError() error {
+	return search.expressionError
+}
+
 func (search *Search) For(s string) *Search {
+	return search.ForWithFlags(s, Flags{})
+}
+
+func (search *Search) ForWithFlags(s string, flags Flags) *Search {
 	search.findMe = s
+	search.flags = flags
+	search.expression = nil
+	search.expressionError = nil
 	if s == "" {
 		// No search
 		search.pattern = nil
@@ -47,9 +141,16 @@ func (search *Search) For(s string) *Search {
 	search.pattern, err = regexp.Compile(s)
 	isValidRegexp := err == nil
 	regexpMatchingRequired := hasSpecialChars && isValidRegexp
+	if flags.Regexp {
+		// Invalid regexps still fall back to substring search
+		regexpMatchingRequired = isValidRegexp
+	}
+	if flags.Literal {
+		regexpMatchingRequired = false
+	}
 	search.isSubstringSearch = !regexpMatchingRequired
 
-	search.hasUppercase = false
+	search.hasUppercase = flags.CaseSensitive
 	for _, char := range s {
 		if unicode.IsUpper(char) {
 			search.hasUppercase = true
@@ -57,6 +158,17 @@ func (search *Search) For(s string) *Search {
 		}
 	}
 
+	if flags.WholeWord {
+		// Whole words require word boundaries, which only regexps can do
+		wordPattern := s
+		if search.isSubstringSearch {
+			wordPattern = regexp.QuoteMeta(s)
+		}
+		search.pattern = regexp.MustCompile(`\b(?:` + wordPattern + `)\b`)
+		search.isSubstringSearch = false
+		return search
+	}
+
 	if search.isSubstringSearch {
 		// No need to compile a regexp pattern since GetMatchRanges and Matches
 		// use fast paths for substring searches.
@@ -73,7 +185,10 @@ func (search *Search) For(s string) *Search {
 
 func (search *Search) Clear() {
 	search.findMe = ""
+	search.flags = Flags{}
 	search.pattern = nil
+	search.expression = nil
+	search.expressionError = nil
 }
 
 func (search Search) Active() bool {
@@ -89,6 +204,10 @@ func (search Search) Matches(line string) bool {
 		return false
 	}
 
+	if search.expression != nil {
+		return search.expression.matches(line)
+	}
+
 	if search.isSubstringSearch && search.hasUppercase {
 		// Case sensitive substring search
 		return strings.Contains(line, search.findMe)
@@ -116,6 +235,16 @@ func (search Search) GetMatchRanges(String string) *MatchRanges {
 		return nil
 	}
 
+	if search.expression != nil {
+		if !search.expression.matches(String) {
+			return nil
+		}
+
+		ranges := &MatchRanges{}
+		search.expression.addMatchRanges(String, ranges)
+		return ranges
+	}
+
 	if !search.hasUppercase {
 		// Case insensitive search, lowercase the string. The pattern is already
 		// lowercase whenever hasUppercase is false.
@@ -28,6 +28,34 @@ func TestSearchForMatch(t *testing.T) {
 	assert.Assert(t, For(")g").Matches(")g"))
 }
 
+func TestSearchWithFlags(t *testing.T) {
+	// Forced regexp, no special characters but still a regexp
+	assert.Assert(t, ForWithFlags("a", Flags{Regexp: true}).pattern != nil)
+
+	// Forced literal, special characters are matched as-is
+	assert.Assert(t, ForWithFlags("a.c", Flags{Literal: true}).Matches("a.c"))
+	assert.Assert(t, !ForWithFlags("a.c", Flags{Literal: true}).Matches("abc"))
+
+	// Forced case sensitivity
+	assert.Assert(t, !ForWithFlags("abc", Flags{CaseSensitive: true}).Matches("ABC"))
+	assert.Assert(t, ForWithFlags("abc", Flags{CaseSensitive: true}).Matches("abc"))
+
+	// Whole words
+	assert.Assert(t, ForWithFlags("cat", Flags{WholeWord: true}).Matches("a cat."))
+	assert.Assert(t, !ForWithFlags("cat", Flags{WholeWord: true}).Matches("concatenate"))
+	assert.Assert(t, ForWithFlags("a.c", Flags{WholeWord: true, Literal: true}).Matches("x a.c y"))
+	assert.Assert(t, !ForWithFlags("a.c", Flags{WholeWord: true, Literal: true}).Matches("x abc y"))
+
+	ranges := ForWithFlags("cat", Flags{WholeWord: true}).GetMatchRanges("cat concat cat")
+	assert.DeepEqual(t, ranges.Matches, [][2]int{{0, 3}, {11, 14}})
+
+	// Flags are part of the identity of a search
+	assert.Assert(t, !For("cat").Equals(ForWithFlags("cat", Flags{WholeWord: true})))
+
+	assert.Equal(t, Flags{Regexp: true, WholeWord: true}.String(), "regexp, word")
+	assert.Equal(t, Flags{}.String(), "")
+}
+
 func benchmarkMatch(b *testing.B, searchTerm string) {
 	sourceBytes, err := os.ReadFile("../../sample-files/large-git-log-patch-no-color.txt")
 	assert.NilError(b, err)
@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var errNoFileName = errors.New("no file name to expand % to")
+
+// Quote a string so that the shell treats it as a single word
+func shellQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + s + `"`
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Replace % with the (quoted) file name, and %% with a single %, just like
+// less does
+func expandShellCommand(command string, fileName *string) (string, error) {
+	var expanded strings.Builder
+
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '%' {
+			expanded.WriteRune(runes[i])
+			continue
+		}
+
+		if i+1 < len(runes) && runes[i+1] == '%' {
+			expanded.WriteRune('%')
+			i++
+			continue
+		}
+
+		if fileName == nil {
+			return "", errNoFileName
+		}
+		expanded.WriteString(shellQuote(*fileName))
+	}
+
+	return expanded.String(), nil
+}
+
+// Run a shell command on the terminal, then wait for the user to press RETURN
+// before going back to paging
+func (p *Pager) runShellCommand(command string) {
+	if os.Getenv("LESSSECURE") == "1" {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Not running shell commands since LESSSECURE=1 is set in the environment"}
+		return
+	}
+
+	p.readerLock.Lock()
+	fileName := p.readers[p.currentReader].FileName
+	p.readerLock.Unlock()
+
+	expanded, err := expandShellCommand(command, fileName)
+	if err != nil {
+		p.mode = &PagerModeInfo{Pager: p, Text: "Not running command: " + err.Error()}
+		return
+	}
+
+	env := os.Environ()
+	if fileName != nil {
+		env = append(env, "MOOR_FILE="+*fileName)
+	}
+	if lineNumber := p.editorLineNumber(); lineNumber != nil {
+		env = append(env, fmt.Sprintf("MOOR_LINE=%d", lineNumber.AsOneBased()))
+	}
+
+	err = p.screen.PauseAndCall(func() error {
+		log.Info("'!' pressed, running: ", expanded)
+
+		cmd := shellCommand(expanded)
+		cmd.Env = env
+		cmd.Stdin = os.Stdout // Like for the editor, see handleEditingRequest()
+		if runtime.GOOS == "windows" {
+			cmd.Stdin = os.Stdin
+		}
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		err := cmd.Run()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "\n%s: %v\n", expanded, err)
+		}
+		waitForReturn()
+
+		return err
+	})
+	if err != nil {
+		log.Infof("Shell command %q failed: %v", expanded, err)
+		p.mode = &PagerModeInfo{Pager: p, Text: "Command failed: " + err.Error()}
+		return
+	}
+
+	p.mode = PagerModeViewing{pager: p}
+}
@@ -0,0 +1,27 @@
+package internal
+
+import (
+	"runtime"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestExpandShellCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Quoting is different on Windows")
+	}
+
+	fileName := "it's.txt"
+
+	expanded, err := expandShellCommand("wc -l %", &fileName)
+	assert.NilError(t, err)
+	assert.Equal(t, expanded, `wc -l 'it'\''s.txt'`)
+
+	expanded, err = expandShellCommand("printf 100%%", nil)
+	assert.NilError(t, err)
+	assert.Equal(t, expanded, "printf 100%")
+
+	_, err = expandShellCommand("cat %", nil)
+	assert.ErrorIs(t, err, errNoFileName)
+}
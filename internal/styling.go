@@ -25,9 +25,36 @@ var plainTextStyle = twin.StyleDefault
 
 var searchHitStyle = twin.StyleDefault.WithAttr(twin.AttrReverse)
 
+// Styles for persistent highlights, handed out in this order. Ref:
+// Pager.highlights.
+var highlightStyles = []twin.Style{
+	twin.StyleDefault.WithForeground(twin.NewColor16(0)).WithBackground(twin.NewColor16(3)),
+	twin.StyleDefault.WithForeground(twin.NewColor16(0)).WithBackground(twin.NewColor16(2)),
+	twin.StyleDefault.WithForeground(twin.NewColor16(0)).WithBackground(twin.NewColor16(6)),
+	twin.StyleDefault.WithForeground(twin.NewColor16(0)).WithBackground(twin.NewColor16(5)),
+	twin.StyleDefault.WithForeground(twin.NewColor16(7)).WithBackground(twin.NewColor16(4)),
+	twin.StyleDefault.WithForeground(twin.NewColor16(7)).WithBackground(twin.NewColor16(1)),
+}
+
 // This can be nil
 var searchHitLineBackground *twin.Color
 
+// Mixed into the line backgrounds when comparing files, unless the Chroma style
+// has colors for inserted and deleted text
+var diffAddedColor = twin.NewColor24Bit(0x00, 0xc0, 0x00)
+var diffRemovedColor = twin.NewColor24Bit(0xc0, 0x00, 0x00)
+var diffChangedColor = twin.NewColor24Bit(0x40, 0x80, 0xff)
+
+// Line backgrounds when comparing files. These defaults assume a black
+// background, see configureDiffBackgrounds() for the real ones.
+var diffAddedBackground = twin.NewColor24Bit(0, 0, 0).Mix(diffAddedColor, 0.2)
+var diffRemovedBackground = twin.NewColor24Bit(0, 0, 0).Mix(diffRemovedColor, 0.2)
+var diffChangedBackground = twin.NewColor24Bit(0, 0, 0).Mix(diffChangedColor, 0.2)
+
+// Background of the cursor line. This default assumes a black background, see
+// configureCursorLineBackground() for the real one.
+var cursorLineBackground = twin.NewColor24Bit(0, 0, 0).Mix(twin.NewColor24Bit(255, 255, 255), 0.15)
+
 func setStyle(updateMe *twin.Style, envVarName string, fallback *twin.Style) {
 	envValue := os.Getenv(envVarName)
 	if envValue == "" {
@@ -195,6 +222,56 @@ func styleUI(terminalBackground *twin.Color, chromaStyle *chroma.Style, chromaFo
 	statusbarFileStyle = statusbarStyle.WithAttr(twin.AttrUnderline)
 
 	configureHighlighting(terminalBackground, configureSearchHitLineBackground)
+	configureDiffBackgrounds(terminalBackground, chromaStyle, chromaFormatter)
+	configureCursorLineBackground(terminalBackground)
+}
+
+// Our best guess at what color plain text is shown on. Expects plainTextStyle
+// to have been set.
+func guessPlainBackground(terminalBackground *twin.Color) twin.Color {
+	if terminalBackground != nil {
+		return *terminalBackground
+	}
+	if plainTextStyle.HasAttr(twin.AttrReverse) && plainTextStyle.Foreground() != twin.ColorDefault {
+		return plainTextStyle.Foreground()
+	}
+	if plainTextStyle.Background() != twin.ColorDefault {
+		return plainTextStyle.Background()
+	}
+
+	return twin.NewColor24Bit(0, 0, 0) // Same guess as GetStyleForScreen()
+}
+
+// Expects to be called from the end of styleUI(), after plainTextStyle has been
+// set.
+func configureCursorLineBackground(terminalBackground *twin.Color) {
+	plainBg := guessPlainBackground(terminalBackground)
+	cursorLineBackground = plainBg.Mix(getOppositeColor(plainBg), 0.15)
+	log.Trace("Cursor line background set to ", cursorLineBackground)
+}
+
+// Expects to be called from the end of styleUI(), after plainTextStyle has been
+// set.
+func configureDiffBackgrounds(terminalBackground *twin.Color, chromaStyle *chroma.Style, chromaFormatter *chroma.Formatter) {
+	plainBg := guessPlainBackground(terminalBackground)
+
+	added := diffAddedColor
+	inserted := twinStyleFromChroma(terminalBackground, chromaStyle, chromaFormatter, chroma.GenericInserted, true)
+	if inserted != nil && inserted.Foreground() != twin.ColorDefault {
+		added = inserted.Foreground()
+	}
+
+	removed := diffRemovedColor
+	deleted := twinStyleFromChroma(terminalBackground, chromaStyle, chromaFormatter, chroma.GenericDeleted, true)
+	if deleted != nil && deleted.Foreground() != twin.ColorDefault {
+		removed = deleted.Foreground()
+	}
+
+	// Same mix as for the search hit line background
+	diffAddedBackground = plainBg.Mix(added, 0.2)
+	diffRemovedBackground = plainBg.Mix(removed, 0.2)
+	diffChangedBackground = plainBg.Mix(diffChangedColor, 0.2)
+	log.Trace("Diff line backgrounds set to ", diffAddedBackground, ", ", diffRemovedBackground, " and ", diffChangedBackground)
 }
 
 // Expects to be called from the end of styleUI(), since at that
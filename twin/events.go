@@ -17,10 +17,41 @@ const (
 	MouseWheelDown
 	MouseWheelLeft
 	MouseWheelRight
+	MouseButtonLeft
+	MouseButtonMiddle
+	MouseButtonRight
+)
+
+type MouseAction int
+
+const (
+	// A button was pressed, or the wheel was turned
+	MouseActionPress MouseAction = iota
+
+	// A button was released
+	MouseActionRelease
+
+	// The mouse moved while a button was held down
+	MouseActionMotion
+)
+
+type ModifierMask uint8
+
+const (
+	ModifierShift ModifierMask = 1 << iota
+	ModifierAlt
+	ModifierCtrl
 )
 
 type EventMouse struct {
 	buttons MouseButtonMask
+	action  MouseAction
+
+	// Zero based screen coordinates
+	column int
+	row    int
+
+	modifiers ModifierMask
 }
 
 // After you get this, query Screen.Size() to get the new size
@@ -47,3 +78,18 @@ func (eventKeyCode *EventKeyCode) KeyCode() KeyCode {
 func (eventMouse *EventMouse) Buttons() MouseButtonMask {
 	return eventMouse.buttons
 }
+
+func (eventMouse *EventMouse) Action() MouseAction {
+	return eventMouse.action
+}
+
+// Zero based screen coordinates of the mouse pointer
+func (eventMouse *EventMouse) Position() (column int, row int) {
+	return eventMouse.column, eventMouse.row
+}
+
+// Which modifier keys were held down. Note that many terminals use some
+// modifiers for their own purposes, like selecting text while Shift is held.
+func (eventMouse *EventMouse) Modifiers() ModifierMask {
+	return eventMouse.modifiers
+}
@@ -7,6 +7,9 @@ type FakeScreen struct {
 	width  int
 	height int
 	cells  [][]StyledRune
+
+	// The latest text passed to CopyToClipboard()
+	clipboard string
 }
 
 func NewFakeScreen(width int, height int) *FakeScreen {
@@ -118,6 +121,21 @@ func (screen *FakeScreen) GetRow(row int) []StyledRune {
 	return withoutHiddenRunes(screen.cells[row])
 }
 
+func (screen *FakeScreen) CopyToClipboard(text string) error {
+	err := checkClipboardSize(text)
+	if err != nil {
+		return err
+	}
+
+	screen.clipboard = text
+	return nil
+}
+
+// What was last passed to CopyToClipboard()
+func (screen *FakeScreen) Clipboard() string {
+	return screen.clipboard
+}
+
 func (screen *FakeScreen) PauseAndCall(run func() error) error {
 	// The fake screen doesn't have any special state to save and restore, just
 	// run it.
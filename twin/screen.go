@@ -2,6 +2,7 @@
 package twin
 
 import (
+	"encoding/base64"
 	"fmt"
 	"os"
 	"regexp"
@@ -76,6 +77,14 @@ type Screen interface {
 	// Pause the screen, run the given function, then resume the screen. Blocks
 	// until the function has completed and the screen has been resumed again.
 	PauseAndCall(run func() error) error
+
+	// Put text on the system clipboard. This uses the OSC 52 escape sequence,
+	// which works over SSH as well. Terminals not supporting OSC 52 will
+	// silently ignore this.
+	//
+	// Returns an error without copying anything if the text is too large for
+	// terminals to accept.
+	CopyToClipboard(text string) error
 }
 
 type lastRendered struct {
@@ -122,11 +131,15 @@ type UnixScreen struct {
 //
 // Where:
 //   - "\x1b[<" says this is a mouse event
-//   - "65" says this is Wheel Up. "64" would be Wheel Down.
+//   - "65" says this is Wheel Down. "64" would be Wheel Up. See
+//     decodeMouseEvent() for the details.
 //   - "127" is the column number on screen, "1" is the first column.
 //   - "41" is the row number on screen, "1" is the first row.
-//   - "M" marks the end of the mouse event.
-var mouseEventRegex = regexp.MustCompile("^\x1b\\[<([0-9]+);([0-9]+);([0-9]+)M")
+//   - "M" marks the end of the mouse event. "m" would mean a button was
+//     released.
+//
+// Ref: https://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h3-Extended-coordinates
+var mouseEventRegex = regexp.MustCompile("^\x1b\\[<([0-9]+);([0-9]+);([0-9]+)([Mm])")
 
 // NewScreen() requires Close() to be called after you are done with your new
 // screen, most likely somewhere in your shutdown code.
@@ -455,9 +468,11 @@ func terminalHasArrowKeysEmulation() bool {
 // You must hold renderLock when calling this method.
 func (screen *UnixScreen) enableMouseTrackingLocked(enable bool) {
 	if enable {
-		screen.writeLocked("\x1b[?1006;1000h")
+		// 1002 reports button presses and releases, and motion while a
+		// button is held down
+		screen.writeLocked("\x1b[?1006;1002h")
 	} else {
-		screen.writeLocked("\x1b[?1006;1000l")
+		screen.writeLocked("\x1b[?1006;1002l")
 	}
 }
 
@@ -590,12 +605,9 @@ func consumeEncodedEvent(encodedEventSequences string) (*Event, string) {
 
 	mouseMatch := mouseEventRegex.FindStringSubmatch(encodedEventSequences)
 	if mouseMatch != nil {
-		if mouseMatch[1] == "64" {
-			var event Event = EventMouse{buttons: MouseWheelUp}
-			return &event, strings.TrimPrefix(encodedEventSequences, mouseMatch[0])
-		}
-		if mouseMatch[1] == "65" {
-			var event Event = EventMouse{buttons: MouseWheelDown}
+		mouseEvent := decodeMouseEvent(mouseMatch[1], mouseMatch[2], mouseMatch[3], mouseMatch[4] == "m")
+		if mouseEvent != nil {
+			var event Event = *mouseEvent
 			return &event, strings.TrimPrefix(encodedEventSequences, mouseMatch[0])
 		}
 
@@ -639,6 +651,72 @@ func consumeEncodedEvent(encodedEventSequences string) (*Event, string) {
 	return &event, string(runes[1:])
 }
 
+// Decode the numbers of an SGR mouse report. Returns nil for reports we don't
+// support, like the extra buttons on some mice.
+func decodeMouseEvent(buttonCode string, column string, row string, released bool) *EventMouse {
+	code, err := strconv.Atoi(buttonCode)
+	if err != nil {
+		return nil
+	}
+	x, err := strconv.Atoi(column)
+	if err != nil {
+		return nil
+	}
+	y, err := strconv.Atoi(row)
+	if err != nil {
+		return nil
+	}
+
+	event := EventMouse{
+		action: MouseActionPress,
+		column: x - 1,
+		row:    y - 1,
+	}
+
+	if code&4 != 0 {
+		event.modifiers |= ModifierShift
+	}
+	if code&8 != 0 {
+		event.modifiers |= ModifierAlt
+	}
+	if code&16 != 0 {
+		event.modifiers |= ModifierCtrl
+	}
+	if code&32 != 0 {
+		event.action = MouseActionMotion
+	}
+	if released {
+		event.action = MouseActionRelease
+	}
+
+	// The low two bits are the button, and bit 6 says whether it's a wheel
+	button := code & 3
+	switch code &^ (4 | 8 | 16 | 32 | 3) {
+	case 0:
+		switch button {
+		case 0:
+			event.buttons = MouseButtonLeft
+		case 1:
+			event.buttons = MouseButtonMiddle
+		case 2:
+			event.buttons = MouseButtonRight
+		default:
+			if event.action != MouseActionMotion {
+				return nil
+			}
+			// Motion without any button held down, leave buttons empty
+		}
+
+	case 64:
+		event.buttons = []MouseButtonMask{MouseWheelUp, MouseWheelDown, MouseWheelLeft, MouseWheelRight}[button]
+
+	default:
+		return nil
+	}
+
+	return &event
+}
+
 // Returns screen width and height.
 //
 // NOTE: Never cache this response! On window resizes you'll get an EventResize
@@ -1156,6 +1234,37 @@ func (screen *UnixScreen) PauseAndCall(run func() error) error {
 	return nil
 }
 
+// Terminals drop OSC 52 sequences with more base64 encoded bytes than this.
+// Some accept more, but not all.
+const maxClipboardBase64Bytes = 100_000
+
+// Returns an error if the text is too large to copy using OSC 52
+func checkClipboardSize(text string) error {
+	encodedLength := base64.StdEncoding.EncodedLen(len(text))
+	if encodedLength > maxClipboardBase64Bytes {
+		return fmt.Errorf("%dkB is too much for the terminal, max is %dkB",
+			len(text)/1000, base64.StdEncoding.DecodedLen(maxClipboardBase64Bytes)/1000)
+	}
+
+	return nil
+}
+
+// Ref: https://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h3-Operating-System-Commands
+func (screen *UnixScreen) CopyToClipboard(text string) error {
+	err := checkClipboardSize(text)
+	if err != nil {
+		return err
+	}
+
+	screen.renderLock.Lock()
+	defer screen.renderLock.Unlock()
+
+	// "c" is for the clipboard, as opposed to the primary selection. BEL
+	// rather than ST as terminator since more terminals understand it.
+	screen.writeLocked("\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a")
+	return nil
+}
+
 func (screen *UnixScreen) restoreRawModeAfterResume() error {
 	terminalState, err := term.MakeRaw(int(screen.ttyIn.Fd()))
 	if err != nil {
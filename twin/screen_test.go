@@ -32,8 +32,8 @@ func TestConsumeEncodedEvent(t *testing.T) {
 	// Implicitly test having a remaining rune at the end
 	assertEncode(t, "\x1b[Ax", EventKeyCode{keyCode: KeyUp}, "x")
 
-	assertEncode(t, "\x1b[<64;127;41M", EventMouse{buttons: MouseWheelUp}, "")
-	assertEncode(t, "\x1b[<65;127;41M", EventMouse{buttons: MouseWheelDown}, "")
+	assertEncode(t, "\x1b[<64;127;41M", EventMouse{buttons: MouseWheelUp, column: 126, row: 40}, "")
+	assertEncode(t, "\x1b[<65;127;41M", EventMouse{buttons: MouseWheelDown, column: 126, row: 40}, "")
 
 	// This happens when users paste.
 	//
@@ -41,6 +41,35 @@ func TestConsumeEncodedEvent(t *testing.T) {
 	assertEncode(t, "1234", EventRune{rune: '1'}, "234")
 }
 
+func TestConsumeEncodedMouseEvent(t *testing.T) {
+	assertEncode(t, "\x1b[<0;1;1M", EventMouse{buttons: MouseButtonLeft}, "")
+	assertEncode(t, "\x1b[<0;10;5m", EventMouse{buttons: MouseButtonLeft, action: MouseActionRelease, column: 9, row: 4}, "")
+	assertEncode(t, "\x1b[<1;2;3M", EventMouse{buttons: MouseButtonMiddle, column: 1, row: 2}, "")
+	assertEncode(t, "\x1b[<2;2;3M", EventMouse{buttons: MouseButtonRight, column: 1, row: 2}, "")
+
+	// Dragging with the left button
+	assertEncode(t, "\x1b[<32;7;8M", EventMouse{buttons: MouseButtonLeft, action: MouseActionMotion, column: 6, row: 7}, "")
+
+	// Shift and Ctrl held down while clicking
+	assertEncode(t, "\x1b[<20;1;1M", EventMouse{buttons: MouseButtonLeft, modifiers: ModifierShift | ModifierCtrl}, "")
+
+	// Alt held down while scrolling
+	assertEncode(t, "\x1b[<72;1;1M", EventMouse{buttons: MouseWheelUp, modifiers: ModifierAlt}, "")
+
+	assertEncode(t, "\x1b[<66;1;1M", EventMouse{buttons: MouseWheelLeft}, "")
+	assertEncode(t, "\x1b[<67;1;1M", EventMouse{buttons: MouseWheelRight}, "")
+
+	// Two events in a row
+	assertEncode(t, "\x1b[<0;1;1M\x1b[<0;1;1m", EventMouse{buttons: MouseButtonLeft}, "\x1b[<0;1;1m")
+}
+
+func TestConsumeEncodedEventWithUnsupportedMouseButton(t *testing.T) {
+	// Button 8, the "back" button on some mice
+	event, remainder := consumeEncodedEvent("\x1b[<128;1;1M")
+	assert.Assert(t, event == nil)
+	assert.Equal(t, remainder, "")
+}
+
 func TestConsumeEncodedEventWithUnsupportedEscapeCode(t *testing.T) {
 	event, remainder := consumeEncodedEvent("\x1bXXXXX")
 	assert.Assert(t, event == nil)
@@ -0,0 +1,99 @@
+package twin
+
+// A rectangular part of another screen. Drawing outside of the rectangle does
+// nothing, and everything that isn't about drawing goes to the parent screen.
+//
+// Used for splitting the screen into panes.
+type SubScreen struct {
+	parent Screen
+
+	column int
+	row    int
+	width  int
+	height int
+}
+
+// The rectangle is clipped to the parent screen
+func NewSubScreen(parent Screen, column int, row int, width int, height int) *SubScreen {
+	parentWidth, parentHeight := parent.Size()
+	column = max(0, min(column, parentWidth))
+	row = max(0, min(row, parentHeight))
+
+	return &SubScreen{
+		parent: parent,
+		column: column,
+		row:    row,
+		width:  max(0, min(width, parentWidth-column)),
+		height: max(0, min(height, parentHeight-row)),
+	}
+}
+
+func (screen *SubScreen) Close() {
+	screen.parent.Close()
+}
+
+// Clears only our part of the parent screen
+func (screen *SubScreen) Clear() {
+	empty := NewStyledRune(' ', StyleDefault)
+	for row := range screen.height {
+		for column := range screen.width {
+			screen.parent.SetCell(screen.column+column, screen.row+row, empty)
+		}
+	}
+}
+
+func (screen *SubScreen) SetCell(column int, row int, styledRune StyledRune) int {
+	if column < 0 || row < 0 || column >= screen.width || row >= screen.height {
+		return styledRune.Width()
+	}
+
+	if column+styledRune.Width() > screen.width {
+		// This cell is too wide for our part of the screen, write a space
+		// instead
+		screen.parent.SetCell(screen.column+column, screen.row+row, NewStyledRune(' ', styledRune.Style))
+		return styledRune.Width()
+	}
+
+	return screen.parent.SetCell(screen.column+column, screen.row+row, styledRune)
+}
+
+func (screen *SubScreen) GetCell(column int, row int) StyledRune {
+	if column < 0 || row < 0 || column >= screen.width || row >= screen.height {
+		return NewStyledRune(' ', StyleDefault)
+	}
+
+	return screen.parent.GetCell(screen.column+column, screen.row+row)
+}
+
+func (screen *SubScreen) Show() {
+	screen.parent.Show()
+}
+
+func (screen *SubScreen) ShowNLines(lineCountToShow int) {
+	screen.parent.ShowNLines(lineCountToShow)
+}
+
+func (screen *SubScreen) Size() (width int, height int) {
+	return screen.width, screen.height
+}
+
+func (screen *SubScreen) TerminalBackground() *Color {
+	return screen.parent.TerminalBackground()
+}
+
+func (screen *SubScreen) Events() chan Event {
+	return screen.parent.Events()
+}
+
+func (screen *SubScreen) PauseAndCall(run func() error) error {
+	return screen.parent.PauseAndCall(run)
+}
+
+func (screen *SubScreen) CopyToClipboard(text string) error {
+	return screen.parent.CopyToClipboard(text)
+}
+
+// Where our top left corner is on the parent screen
+func (screen *SubScreen) Origin() (column int, row int) {
+	return screen.column, screen.row
+}
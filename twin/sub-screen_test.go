@@ -0,0 +1,43 @@
+package twin
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func rowString(screen *FakeScreen, row int) string {
+	result := ""
+	for _, cell := range screen.GetRow(row) {
+		result += string(cell.Rune)
+	}
+	return result
+}
+
+func TestSubScreen(t *testing.T) {
+	parent := NewFakeScreen(6, 3)
+	parent.Clear()
+
+	sub := NewSubScreen(parent, 2, 1, 3, 5)
+	width, height := sub.Size()
+	assert.Equal(t, width, 3)
+	assert.Equal(t, height, 2, "Should be clipped to the parent screen")
+
+	for column, char := range "abcde" {
+		sub.SetCell(column, 0, NewStyledRune(char, StyleDefault))
+	}
+	sub.SetCell(0, 1, NewStyledRune('x', StyleDefault))
+	sub.SetCell(0, 2, NewStyledRune('y', StyleDefault))
+
+	assert.Equal(t, rowString(parent, 0), "      ")
+	assert.Equal(t, rowString(parent, 1), "  abc ")
+	assert.Equal(t, rowString(parent, 2), "  x   ")
+	assert.Equal(t, sub.GetCell(1, 0).Rune, 'b')
+
+	// A wide rune in the last column of the sub screen must not overflow
+	sub.SetCell(2, 1, NewStyledRune('午', StyleDefault))
+	assert.Equal(t, rowString(parent, 2), "  x   ")
+
+	sub.Clear()
+	assert.Equal(t, rowString(parent, 1), "      ")
+}